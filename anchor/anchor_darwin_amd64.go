@@ -6,7 +6,6 @@ import (
 	_ "embed"
 	"os"
 	"os/exec"
-	"path/filepath"
 
 	pb "github.com/veil-net/conflux/proto"
 	"google.golang.org/grpc"
@@ -17,7 +16,8 @@ import (
 //go:embed bin/anchor-darwin-amd64
 var anchorPlugin []byte
 
-// NewAnchor extracts the embedded binary to a temp file and starts it as a subprocess (gRPC server).
+// NewAnchor extracts the embedded binary privately and starts it as a subprocess (gRPC server).
+// Use WaitReady before dialing it, and Exited instead of Wait.
 //
 // Inputs: none.
 //
@@ -25,11 +25,9 @@ var anchorPlugin []byte
 //   - *exec.Cmd. The started anchor subprocess.
 //   - err: error. Non-nil if the binary cannot be extracted or started.
 func NewAnchor() (*exec.Cmd, error) {
-	// Extract the embedded file to a temporary directory
-	pluginPath := filepath.Join(os.TempDir(), "anchor")
-	// Remove existing file if it exists to avoid "text file busy" error
-	os.Remove(pluginPath)
-	if err := os.WriteFile(pluginPath, anchorPlugin, 0755); err != nil {
+	// Extract the embedded file where other users cannot replace it, see extractAnchor
+	pluginPath, cleanup, err := extractAnchor(anchorPlugin, anchorPluginName("anchor"))
+	if err != nil {
 		return nil, err
 	}
 
@@ -40,27 +38,32 @@ func NewAnchor() (*exec.Cmd, error) {
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Start(); err != nil {
+		cleanup()
 		return nil, err
 	}
 
 	// Verify the process started successfully
 	if cmd.Process == nil {
+		cleanup()
 		return nil, exec.ErrNotFound
 	}
 
+	// Reap the process when it exits and remove the extracted binary, see Exited
+	watchExit(cmd, cleanup)
+
 	return cmd, nil
 }
 
-// NewAnchorClient creates a gRPC client connected to the local anchor server (127.0.0.1:1993).
+// NewAnchorClient creates a gRPC client connected to the local anchor server of the selected instance (127.0.0.1:1993 by default).
 //
 // Inputs: none.
 //
 // Outputs:
-//   - pb.AnchorClient. The gRPC client connected to 127.0.0.1:1993.
+//   - pb.AnchorClient. The gRPC client connected to AnchorAddr.
 //   - err: error. Non-nil if the connection fails.
 func NewAnchorClient() (pb.AnchorClient, error) {
 	// Create a gRPC client connection
-	conn, err := grpc.NewClient("127.0.0.1:1993", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(AnchorAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return nil, err
 	}
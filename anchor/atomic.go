@@ -0,0 +1,64 @@
+package anchor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// writeFileAtomic replaces path with data so readers and a crash only ever see the old or the new content: the data
+// is written and synced to a temporary file in the same directory, which is then renamed over path.
+//
+// Inputs:
+//   - path: string. The file to write.
+//   - data: []byte. The new content.
+//   - perm: os.FileMode. The mode of the new file, applied even if path already exists with another mode.
+//
+// Outputs:
+//   - err: error. Non-nil if a step fails; path is left untouched in that case.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			file.Close()
+			os.Remove(tempPath)
+		}
+	}()
+
+	if err := file.Chmod(perm); err != nil && runtime.GOOS != "windows" {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return err
+	}
+	committed = true
+	return syncDir(dir)
+}
+
+// syncDir flushes a directory entry change such as a rename to disk; Windows has no directory sync and is skipped.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
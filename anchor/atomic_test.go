@@ -0,0 +1,120 @@
+package anchor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conflux.json")
+	if err := os.WriteFile(path, []byte("old content that is longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatalf("writeFileAtomic error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %04o, want 0600", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want no leftover temporary files", len(entries))
+	}
+}
+
+func TestWriteFileAtomicMissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "conflux.json")
+	if err := writeFileAtomic(path, []byte("x"), 0600); err == nil {
+		t.Error("writeFileAtomic into a missing directory error = nil")
+	}
+}
+
+func TestLockConfigDirExcludes(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := lockConfigDir(dir)
+	if err != nil {
+		t.Fatalf("lockConfigDir error = %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		second, err := lockConfigDir(dir)
+		if err != nil {
+			t.Errorf("second lockConfigDir error = %v", err)
+			close(acquired)
+			return
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lockConfigDir took the lock while it was held")
+	case <-time.After(200 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case second, ok := <-acquired:
+		if ok {
+			second()
+		}
+	case <-time.After(lockTimeout):
+		t.Fatal("second lockConfigDir did not take the lock after it was released")
+	}
+}
+
+func TestUpdateConfigConcurrent(t *testing.T) {
+	useConfigDir(t)
+	config := validConfig()
+	if err := SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig error = %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := UpdateConfig(func(config *ConfluxConfig) error {
+				config.Taints = append(config.Taints, fmt.Sprintf("taint-%d", i))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("UpdateConfig error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error = %v", err)
+	}
+	if len(loaded.Taints) != writers {
+		t.Fatalf("taints = %v, want one from each of %d writers", loaded.Taints, writers)
+	}
+	for i := range writers {
+		if !slices.Contains(loaded.Taints, fmt.Sprintf("taint-%d", i)) {
+			t.Errorf("taints = %v, missing taint-%d", loaded.Taints, i)
+		}
+	}
+}
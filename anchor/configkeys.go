@@ -0,0 +1,241 @@
+package anchor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces secret values in config output.
+const RedactedValue = "<redacted>"
+
+// secretConfigKeys are the config keys whose values are credentials.
+var secretConfigKeys = []string{"conflux_token", "idp.jwt"}
+
+// readOnlyConfigKeys are the config keys that cannot be set.
+var readOnlyConfigKeys = []string{"schema_version", "conflux_token_ref_owned"}
+
+// RuntimeConfigKeys are the config keys the running anchor can apply without a restart.
+var RuntimeConfigKeys = []string{"taints"}
+
+// ErrUnknownConfigKey is returned for keys that do not name a config field.
+var ErrUnknownConfigKey = errors.New("unknown config key")
+
+// IsSecretConfigKey reports whether key holds a credential that must be redacted.
+func IsSecretConfigKey(key string) bool {
+	return slices.Contains(secretConfigKeys, key)
+}
+
+// ConfigKeys returns the dotted keys of every settable scalar or list field, in declaration order.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - []string. Keys such as "guardian", "taints", or "tracer.endpoint".
+func ConfigKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			ft := t.Field(i).Type
+			if ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct {
+				walk(ft.Elem(), prefix+name+".")
+				continue
+			}
+			keys = append(keys, prefix+name)
+		}
+	}
+	walk(reflect.TypeOf(ConfluxConfig{}), "")
+	return keys
+}
+
+// GetConfigValue returns the value of a dotted config key; unset nested sections read as zero values.
+//
+// Inputs:
+//   - config: *ConfluxConfig. The config.
+//   - key: string. A key from ConfigKeys.
+//
+// Outputs:
+//   - any. The value.
+//   - err: error. Wraps ErrUnknownConfigKey if key does not name a field.
+func GetConfigValue(config *ConfluxConfig, key string) (any, error) {
+	v, err := configField(reflect.ValueOf(config).Elem(), key, false)
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsValid() {
+		return reflect.Zero(fieldType(reflect.TypeOf(*config), key)).Interface(), nil
+	}
+	return v.Interface(), nil
+}
+
+// SetConfigValue parses value for the type of a dotted config key and stores it, creating nested sections as needed.
+// Booleans accept strconv.ParseBool forms; lists are comma-separated, and an empty value clears them.
+//
+// Inputs:
+//   - config: *ConfluxConfig. The config to modify.
+//   - key: string. A key from ConfigKeys.
+//   - value: string. The new value.
+//
+// Outputs:
+//   - err: error. Non-nil if the key is unknown or read-only, or value does not parse.
+func SetConfigValue(config *ConfluxConfig, key string, value string) error {
+	if slices.Contains(readOnlyConfigKeys, key) {
+		return fmt.Errorf("config key %q is read-only", key)
+	}
+	v, err := configField(reflect.ValueOf(config).Elem(), key, true)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config key %q expects true or false, got %q", key, value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("config key %q expects an integer, got %q", key, value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" && !slices.Contains(items, item) {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("config key %q cannot be set from the command line", key)
+	}
+	return nil
+}
+
+// DiffConfig returns the keys whose values differ between two configs, in ConfigKeys order.
+//
+// Inputs:
+//   - previous: *ConfluxConfig. The old config.
+//   - current: *ConfluxConfig. The new config.
+//
+// Outputs:
+//   - []string. The changed keys; taints compare as sets.
+func DiffConfig(previous *ConfluxConfig, current *ConfluxConfig) []string {
+	var changed []string
+	for _, key := range ConfigKeys() {
+		before, _ := GetConfigValue(previous, key)
+		after, _ := GetConfigValue(current, key)
+		if a, ok := before.([]string); ok {
+			b, _ := after.([]string)
+			if !sameSet(a, b) {
+				changed = append(changed, key)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(before, after) {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+// sameSet reports whether two string slices hold the same items, ignoring order and duplicates.
+func sameSet(a []string, b []string) bool {
+	for _, item := range a {
+		if !slices.Contains(b, item) {
+			return false
+		}
+	}
+	for _, item := range b {
+		if !slices.Contains(a, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// configField walks a dotted key through struct fields by their JSON names.
+//
+// Inputs:
+//   - v: reflect.Value. The addressable struct.
+//   - key: string. The dotted key.
+//   - create: bool. Allocate nil nested sections instead of returning an invalid value.
+//
+// Outputs:
+//   - reflect.Value. The field; invalid if a nil section was crossed and create is false.
+//   - err: error. Wraps ErrUnknownConfigKey if a segment does not match.
+func configField(v reflect.Value, key string, create bool) (reflect.Value, error) {
+	segments := strings.Split(key, ".")
+	for i, segment := range segments {
+		field, ok := fieldByJSONName(v.Type(), segment)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%w %q", ErrUnknownConfigKey, key)
+		}
+		v = v.FieldByIndex(field.Index)
+		if i == len(segments)-1 {
+			if v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct {
+				return reflect.Value{}, fmt.Errorf("%w %q: it is a section, use one of its keys", ErrUnknownConfigKey, key)
+			}
+			return v, nil
+		}
+		if v.Kind() != reflect.Pointer || v.Type().Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("%w %q", ErrUnknownConfigKey, key)
+		}
+		if v.IsNil() {
+			if !create {
+				// Still validate the remaining segments
+				_, err := configField(reflect.New(v.Type().Elem()).Elem(), strings.Join(segments[i+1:], "."), false)
+				return reflect.Value{}, err
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// fieldType returns the type of a dotted key, which must already be known to exist.
+func fieldType(t reflect.Type, key string) reflect.Type {
+	for _, segment := range strings.Split(key, ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		field, _ := fieldByJSONName(t, segment)
+		t = field.Type
+	}
+	return t
+}
+
+// fieldByJSONName finds the struct field with the given JSON name.
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonName returns the JSON name of a field, or "" if it is not serialized.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" || !field.IsExported() {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
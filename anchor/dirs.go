@@ -0,0 +1,174 @@
+package anchor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// ConfigDirEnv overrides the config directory, like the --config-dir flag.
+const ConfigDirEnv = "VEILNET_CONFIG_DIR"
+
+const (
+	// systemConfigDir holds conflux.json for system installs on Linux.
+	systemConfigDir = "/etc/conflux"
+	// systemStateDir holds the secrets file, session cache, and runtime files for system installs on Linux.
+	systemStateDir = "/var/lib/conflux"
+	// legacyConfigDir is where conflux kept everything on Linux before the system directories were split.
+	legacyConfigDir = "/root/.config/conflux"
+)
+
+var (
+	configDirOverride string
+	legacyWarning     sync.Once
+)
+
+// SetConfigDir makes GetConfigDir and GetStateDir return dir, taking precedence over VEILNET_CONFIG_DIR; "" restores the defaults.
+//
+// Inputs:
+//   - dir: string. The directory for conflux.json and its state, or "".
+//
+// Outputs: none.
+func SetConfigDir(dir string) {
+	configDirOverride = dir
+}
+
+// ConfigDirOverride returns the directory set with SetConfigDir or VEILNET_CONFIG_DIR, so installed services can be
+// pointed at the same place.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - string. The absolute override directory, or "" if the defaults are in use.
+func ConfigDirOverride() string {
+	dir := configDirOverride
+	if dir == "" {
+		dir = os.Getenv(ConfigDirEnv)
+	}
+	if dir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// GetConfigDir returns the directory holding conflux.json; a named instance uses the instances/<name> subdirectory.
+// An override from SetConfigDir or VEILNET_CONFIG_DIR wins. Otherwise root on Linux uses /etc/conflux, or the legacy
+// /root/.config/conflux while it still holds a config; other users, who can only run conflux in the foreground since the
+// installed service always runs as root, follow the XDG base directory spec; macOS and Windows keep their system
+// locations for root and the service account.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - configDir: string. The config directory path.
+//   - err: error. Non-nil if the directory cannot be determined.
+func GetConfigDir() (string, error) {
+	dir, err := baseConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return instanceDir(dir), nil
+}
+
+// baseConfigDir returns the config directory shared by all instances.
+func baseConfigDir() (string, error) {
+	if dir := ConfigDirOverride(); dir != "" {
+		return dir, nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return windowsDir(), nil
+	case "darwin":
+		if os.Geteuid() == 0 {
+			return "/var/root/Library/Application Support/conflux", nil
+		}
+		userConfigDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(userConfigDir, "conflux"), nil
+	default:
+		if os.Geteuid() == 0 {
+			if useLegacyConfigDir() {
+				return legacyConfigDir, nil
+			}
+			return systemConfigDir, nil
+		}
+		return xdgDir("XDG_CONFIG_HOME", ".config")
+	}
+}
+
+// GetStateDir returns the directory holding the secrets file and runtime files; a named instance uses the
+// instances/<name> subdirectory. It is the config directory except for defaults on Linux, where root uses
+// /var/lib/conflux and other users $XDG_STATE_HOME/conflux.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - stateDir: string. The state directory path.
+//   - err: error. Non-nil if the directory cannot be determined.
+func GetStateDir() (string, error) {
+	dir, err := baseStateDir()
+	if err != nil {
+		return "", err
+	}
+	return instanceDir(dir), nil
+}
+
+// baseStateDir returns the state directory shared by all instances, which also holds the user session cache.
+func baseStateDir() (string, error) {
+	if ConfigDirOverride() != "" || runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return baseConfigDir()
+	}
+	if os.Geteuid() == 0 {
+		if useLegacyConfigDir() {
+			return legacyConfigDir, nil
+		}
+		return systemStateDir, nil
+	}
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// useLegacyConfigDir reports whether a config from before the system directories exists and none has been written
+// since, warning once that it is still in use.
+func useLegacyConfigDir() bool {
+	if _, err := os.Stat(filepath.Join(systemConfigDir, configFileName)); !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(legacyConfigDir, configFileName)); err != nil {
+		return false
+	}
+	legacyWarning.Do(func() {
+		Logger.Sugar().Warnf("using the config in %s; move conflux.json to %s and the other files to %s, or pass --config-dir %s",
+			legacyConfigDir, systemConfigDir, systemStateDir, legacyConfigDir)
+	})
+	return true
+}
+
+// xdgDir returns $<env>/conflux, or ~/<fallback>/conflux when the variable is unset or not absolute.
+func xdgDir(env string, fallback string) (string, error) {
+	if base := os.Getenv(env); filepath.IsAbs(base) {
+		return filepath.Join(base, "conflux"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback, "conflux"), nil
+}
+
+// windowsDir returns %ProgramData%\conflux.
+func windowsDir() string {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = "C:\\ProgramData"
+	}
+	return filepath.Join(programData, "conflux")
+}
@@ -0,0 +1,146 @@
+package anchor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// systemRuntimeDir holds the extracted anchor binary for system installs on Linux.
+	systemRuntimeDir = "/run/conflux"
+	// staleAnchorAge is how old a leftover extracted binary must be before extractAnchor removes it; binaries are
+	// removed when their subprocess exits, so only a killed conflux leaves them behind.
+	staleAnchorAge = time.Minute
+)
+
+var (
+	// extractToMemory is memfdAnchor; tests replace it to extract to a file on Linux too.
+	extractToMemory = memfdAnchor
+	// runtimeEuid is os.Geteuid; tests replace it to pick the runtime directory of another user.
+	runtimeEuid = os.Geteuid
+)
+
+// extractAnchor prepares the embedded anchor binary for execution without a predictable path in a shared directory:
+// a sealed memfd on Linux where available, otherwise a new file with a random name in the private runtime directory.
+//
+// Inputs:
+//   - plugin: []byte. The embedded binary.
+//   - name: string. The plugin name, e.g. anchorPluginName("anchor").
+//
+// Outputs:
+//   - path: string. The path to execute.
+//   - cleanup: func(). Releases the binary; call it once the subprocess has exited or failed to start.
+//   - err: error. Non-nil if the binary cannot be written.
+func extractAnchor(plugin []byte, name string) (path string, cleanup func(), err error) {
+	path, cleanup, err = extractToMemory(plugin, name)
+	if err == nil {
+		return path, cleanup, nil
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		Logger.Sugar().Debugf("cannot run the anchor from memory, extracting it to a file: %v", err)
+	}
+
+	dir, err := anchorRuntimeDir()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := ensurePrivateDir(dir); err != nil {
+		return "", nil, err
+	}
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	removeStaleAnchors(dir, prefix, ext)
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", nil, err
+	}
+	path = filepath.Join(dir, prefix+hex.EncodeToString(suffix)+ext)
+	// O_EXCL fails instead of following a symlink or reusing a file someone else created
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, 0700)
+	if err != nil {
+		return "", nil, err
+	}
+	_, err = file.Write(plugin)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", nil, err
+	}
+	return path, func() { os.Remove(path) }, nil
+}
+
+// anchorRuntimeDir returns the directory for the extracted anchor binary of the selected instance: /run/conflux for
+// root on Linux, $XDG_RUNTIME_DIR/conflux for other users on Linux, and <state dir>/runtime otherwise.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - string. The directory path.
+//   - err: error. Non-nil if the state directory cannot be determined.
+func anchorRuntimeDir() (string, error) {
+	if runtime.GOOS == "linux" {
+		if runtimeEuid() == 0 {
+			return instanceDir(systemRuntimeDir), nil
+		}
+		if base := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(base) {
+			return instanceDir(filepath.Join(base, "conflux")), nil
+		}
+	}
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "runtime"), nil
+}
+
+// ensurePrivateDir creates dir with mode 0700 and checks that it is a real directory only the current user controls,
+// so nobody else can swap the binary between writing and running it.
+//
+// Inputs:
+//   - dir: string. The directory.
+//
+// Outputs:
+//   - err: error. Non-nil if the directory cannot be created or is not private.
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("runtime directory %s is not a directory", dir)
+	}
+	if err := checkDirOwner(dir, info); err != nil {
+		return err
+	}
+	// MkdirAll leaves an existing directory untouched, so tighten it explicitly
+	return os.Chmod(dir, 0700)
+}
+
+// removeStaleAnchors removes binaries left behind in dir by a conflux that was killed before its subprocess exited.
+// A binary still in use cannot be removed on Windows and keeps running elsewhere.
+func removeStaleAnchors(dir string, prefix string, ext string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, prefix+"*"+ext))
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() || time.Since(info.ModTime()) < staleAnchorAge {
+			continue
+		}
+		os.Remove(match)
+	}
+}
@@ -0,0 +1,176 @@
+package anchor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"testing/cryptotest"
+	"time"
+)
+
+// extractToFile makes extractAnchor skip the memfd and run as a regular user with $XDG_RUNTIME_DIR set.
+func extractToFile(t *testing.T) (xdgRuntimeDir string) {
+	t.Helper()
+	previousMemory, previousEuid := extractToMemory, runtimeEuid
+	extractToMemory = func(plugin []byte, name string) (string, func(), error) { return "", nil, errors.ErrUnsupported }
+	runtimeEuid = func() int { return 1000 }
+	t.Cleanup(func() { extractToMemory, runtimeEuid = previousMemory, previousEuid })
+	xdgRuntimeDir = t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", xdgRuntimeDir)
+	return xdgRuntimeDir
+}
+
+func TestAnchorRuntimeDir(t *testing.T) {
+	stateDir := useConfigDir(t)
+	xdgRuntimeDir := t.TempDir()
+	tests := []struct {
+		name     string
+		euid     int
+		xdg      string
+		instance string
+		want     string
+	}{
+		{"root", 0, xdgRuntimeDir, "", "/run/conflux"},
+		{"root instance", 0, xdgRuntimeDir, "lab", "/run/conflux/instances/lab"},
+		{"user", 1000, xdgRuntimeDir, "", filepath.Join(xdgRuntimeDir, "conflux")},
+		{"user instance", 1000, xdgRuntimeDir, "lab", filepath.Join(xdgRuntimeDir, "conflux", "instances", "lab")},
+		{"user without runtime dir", 1000, "", "", filepath.Join(stateDir, "runtime")},
+		{"user with relative runtime dir", 1000, "run", "", filepath.Join(stateDir, "runtime")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := runtimeEuid
+			runtimeEuid = func() int { return tt.euid }
+			t.Cleanup(func() { runtimeEuid = previous })
+			t.Setenv("XDG_RUNTIME_DIR", tt.xdg)
+			if err := SetInstance(tt.instance); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { SetInstance("") })
+
+			want := tt.want
+			if runtime.GOOS != "linux" {
+				// Only Linux has a runtime directory; elsewhere the binary goes to the instance's state directory
+				want = filepath.Join(instanceDir(stateDir), "runtime")
+			}
+			got, err := anchorRuntimeDir()
+			if err != nil || got != want {
+				t.Errorf("anchorRuntimeDir = %q, %v, want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestExtractAnchorToFile(t *testing.T) {
+	stateDir := useConfigDir(t)
+	xdgRuntimeDir := extractToFile(t)
+	dir := filepath.Join(xdgRuntimeDir, "conflux")
+	if runtime.GOOS != "linux" {
+		dir = filepath.Join(stateDir, "runtime")
+	}
+
+	path, cleanup, err := extractAnchor([]byte("plugin"), "anchor.exe")
+	if err != nil {
+		t.Fatalf("extractAnchor: %v", err)
+	}
+	if filepath.Dir(path) != dir || !regexp.MustCompile(`^anchor-[0-9a-f]{16}\.exe$`).MatchString(filepath.Base(path)) {
+		t.Errorf("extracted to %s, want a random anchor-<hex>.exe in %s", path, dir)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "plugin" {
+		t.Errorf("extracted content = %q, %v, want %q", content, err, "plugin")
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0700 {
+			t.Errorf("extracted binary mode = %v, %v, want 0700", info.Mode().Perm(), err)
+		}
+		if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+			t.Errorf("runtime directory mode = %v, %v, want 0700", info.Mode().Perm(), err)
+		}
+	}
+
+	other, otherCleanup, err := extractAnchor([]byte("plugin"), "anchor.exe")
+	if err != nil {
+		t.Fatalf("second extractAnchor: %v", err)
+	}
+	defer otherCleanup()
+	if other == path {
+		t.Errorf("two extractions share the path %s", path)
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("binary still exists after cleanup: %v", err)
+	}
+}
+
+func TestExtractAnchorRefusesExistingPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs privileges on Windows")
+	}
+	useConfigDir(t)
+	extractToFile(t)
+
+	// The same random source yields the same name, so the first extraction tells where to plant a link
+	cryptotest.SetGlobalRandom(t, 1)
+	path, cleanup, err := extractAnchor([]byte("plugin"), "anchor")
+	if err != nil {
+		t.Fatalf("extractAnchor: %v", err)
+	}
+	cleanup()
+	target := filepath.Join(t.TempDir(), "target")
+	if err := os.WriteFile(target, []byte("target"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+
+	cryptotest.SetGlobalRandom(t, 1)
+	if _, _, err := extractAnchor([]byte("plugin"), "anchor"); !errors.Is(err, os.ErrExist) {
+		t.Errorf("extractAnchor over a symlink = %v, want os.ErrExist", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "target" {
+		t.Errorf("symlink target was overwritten with %q", content)
+	}
+}
+
+func TestExtractAnchorRemovesStaleBinaries(t *testing.T) {
+	useConfigDir(t)
+	extractToFile(t)
+	dir, err := anchorRuntimeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ensurePrivateDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(dir, "anchor-0000000000000000")
+	fresh := filepath.Join(dir, "anchor-1111111111111111")
+	unrelated := filepath.Join(dir, "other-2222222222222222")
+	for _, file := range []string{stale, fresh, unrelated} {
+		if err := os.WriteFile(file, []byte("old"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * staleAnchorAge)
+	for _, file := range []string{stale, unrelated} {
+		if err := os.Chtimes(file, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, cleanup, err := extractAnchor([]byte("plugin"), "anchor")
+	if err != nil {
+		t.Fatalf("extractAnchor: %v", err)
+	}
+	defer cleanup()
+	for file, kept := range map[string]bool{stale: false, fresh: true, unrelated: true} {
+		if _, err := os.Stat(file); (err == nil) != kept {
+			t.Errorf("%s kept = %t, want %t", filepath.Base(file), err == nil, kept)
+		}
+	}
+}
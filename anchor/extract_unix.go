@@ -0,0 +1,31 @@
+//go:build !windows
+
+package anchor
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openNoFollow makes opening the extracted binary fail if its path is a symlink.
+const openNoFollow = syscall.O_NOFOLLOW
+
+// checkDirOwner checks that dir is owned by the current user.
+//
+// Inputs:
+//   - dir: string. The directory, for the error message.
+//   - info: os.FileInfo. Its Lstat result.
+//
+// Outputs:
+//   - err: error. Non-nil if another user owns it.
+func checkDirOwner(dir string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("runtime directory %s is owned by uid %d, not the current user", dir, stat.Uid)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/veil-net/conflux/secret"
+)
+
+const (
+	// IdentityBundleVersion is the version of the identity bundle format written by this conflux.
+	IdentityBundleVersion = 1
+	// identityExportedFileName is the file under the state directory recording that the identity was exported.
+	identityExportedFileName = "identity-exported.json"
+	// identityTracerDir is the directory under the state directory that imported tracer certificates are written to.
+	identityTracerDir = "tracer"
+	// identityAdditionalData binds the encryption to identity bundles, so other encrypted files are not accepted.
+	identityAdditionalData = "conflux-identity-bundle"
+)
+
+// ErrIdentityExported is returned when this host's identity was exported and must not run here any more.
+var ErrIdentityExported = errors.New("conflux identity was exported to another host")
+
+// IdentityBundle is the content of an identity bundle: the complete config, including the conflux token inline and
+// the tracer certificates, so a conflux can move to another host without re-registering.
+type IdentityBundle struct {
+	Version     int               `json:"version"`
+	ExportedAt  time.Time         `json:"exported_at"`
+	Host        string            `json:"host"`
+	Retired     bool              `json:"retired"`
+	Config      *ConfluxConfig    `json:"config"`
+	TracerFiles map[string]string `json:"tracer_files,omitempty"`
+}
+
+// IdentityExport records that the identity was exported from this host.
+type IdentityExport struct {
+	ConfluxID  string    `json:"conflux_id"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// ExportIdentity builds an identity bundle from the local config. The token is resolved and stored inline, since a
+// secret:// reference may not resolve on the target host, and tracer certificates given as paths or references are
+// embedded.
+//
+// Inputs:
+//   - ctx: context.Context. Request context for the secret backends.
+//   - retired: bool. Whether this host stops using the identity; recorded so import can require --force otherwise.
+//
+// Outputs:
+//   - *IdentityBundle. The bundle.
+//   - err: error. Non-nil if the config cannot be loaded or a tracer certificate cannot be read.
+func ExportIdentity(ctx context.Context, retired bool) (*IdentityBundle, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.TokenRef = ""
+	config.OwnedTokenRef = ""
+	if config.IDP != nil {
+		config.IDP.JWT = ""
+	}
+	host, _ := os.Hostname()
+
+	bundle := &IdentityBundle{
+		Version:     IdentityBundleVersion,
+		ExportedAt:  time.Now().UTC(),
+		Host:        host,
+		Retired:     retired,
+		Config:      config,
+		TracerFiles: map[string]string{},
+	}
+	for name, path := range tracerFiles(config.Tracer) {
+		if *path == "" {
+			continue
+		}
+		var content string
+		if secret.IsRef(*path) {
+			content, err = secret.Resolve(ctx, *path)
+		} else {
+			var data []byte
+			data, err = os.ReadFile(*path)
+			content = string(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tracer %s %s: %w", name, *path, err)
+		}
+		bundle.TracerFiles[name] = content
+		*path = ""
+	}
+	return bundle, nil
+}
+
+// SealIdentity encrypts an identity bundle with a passphrase.
+//
+// Inputs:
+//   - bundle: *IdentityBundle. The bundle.
+//   - passphrase: string. The passphrase.
+//
+// Outputs:
+//   - []byte. The encrypted bundle.
+//   - err: error. Non-nil if encoding or encryption fails.
+func SealIdentity(bundle *IdentityBundle, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Seal(passphrase, plaintext, []byte(identityAdditionalData))
+}
+
+// OpenIdentity decrypts an identity bundle and checks that it is complete.
+//
+// Inputs:
+//   - data: []byte. The encrypted bundle.
+//   - passphrase: string. The passphrase.
+//
+// Outputs:
+//   - *IdentityBundle. The bundle.
+//   - err: error. Non-nil if the passphrase is wrong, the bundle is from a newer conflux, or its config is invalid.
+func OpenIdentity(data []byte, passphrase string) (*IdentityBundle, error) {
+	plaintext, err := secret.Open(passphrase, data, []byte(identityAdditionalData))
+	if err != nil {
+		return nil, err
+	}
+	bundle := &IdentityBundle{}
+	if err := json.Unmarshal(plaintext, bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Version > IdentityBundleVersion {
+		return nil, fmt.Errorf("identity bundle version %d is newer than this conflux supports (%d), upgrade conflux", bundle.Version, IdentityBundleVersion)
+	}
+	if bundle.Config == nil {
+		return nil, errors.New("identity bundle has no config")
+	}
+	bundle.Config.normalize()
+	// Export clears the tracer certificate paths, which ImportIdentity points at the embedded certificates
+	if err := Validate(bundle.Config); err != nil {
+		return nil, fmt.Errorf("identity bundle has an invalid config: %w", err)
+	}
+	return bundle, nil
+}
+
+// ImportIdentity writes the bundle's tracer certificates to the state directory and saves its config, replacing
+// the local one, and clears a record of an earlier export of the same identity from this host.
+//
+// Inputs:
+//   - bundle: *IdentityBundle. The bundle.
+//   - tokenStore: string. A secret:// reference to keep the token in instead of secrets.json, or "".
+//
+// Outputs:
+//   - err: error. Non-nil if a file cannot be written or the config is invalid.
+func ImportIdentity(bundle *IdentityBundle, tokenStore string) error {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return err
+	}
+	config := *bundle.Config
+	tracer := *config.Tracer
+	config.Tracer = &tracer
+	config.TokenRef = tokenStore
+	config.OwnedTokenRef = tokenStore
+
+	if len(bundle.TracerFiles) > 0 {
+		tracerDir := filepath.Join(stateDir, identityTracerDir)
+		if err := ensureConfigDir(tracerDir); err != nil {
+			return err
+		}
+		for name, path := range tracerFiles(config.Tracer) {
+			content, ok := bundle.TracerFiles[name]
+			if !ok {
+				continue
+			}
+			*path = filepath.Join(tracerDir, name+".pem")
+			if err := writeFileAtomic(*path, []byte(content), 0600); err != nil {
+				return err
+			}
+		}
+	}
+	if err := Validate(&config); err != nil {
+		return err
+	}
+	if err := SaveConfig(&config); err != nil {
+		return err
+	}
+
+	exported, err := LoadIdentityExport()
+	if err == nil && exported.ConfluxID == config.ConfluxID {
+		return os.Remove(filepath.Join(stateDir, identityExportedFileName))
+	}
+	return nil
+}
+
+// MarkIdentityExported records that the identity in config was exported, so CheckIdentity stops it from starting
+// here again.
+//
+// Inputs:
+//   - bundle: *IdentityBundle. The exported bundle.
+//
+// Outputs:
+//   - err: error. Non-nil if the record cannot be written.
+func MarkIdentityExported(bundle *IdentityBundle) error {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(&IdentityExport{ConfluxID: bundle.Config.ConfluxID, ExportedAt: bundle.ExportedAt})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(stateDir, identityExportedFileName), record, 0600)
+}
+
+// LoadIdentityExport returns the record of the last identity export from this host.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - *IdentityExport. The record.
+//   - err: error. fs.ErrNotExist if the identity was never exported.
+func LoadIdentityExport() (*IdentityExport, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, identityExportedFileName))
+	if err != nil {
+		return nil, err
+	}
+	exported := &IdentityExport{}
+	if err := json.Unmarshal(data, exported); err != nil {
+		return nil, err
+	}
+	return exported, nil
+}
+
+// CheckIdentity refuses to run a conflux whose identity was exported from this host, so it does not run on two hosts.
+//
+// Inputs:
+//   - config: *ConfluxConfig. The config about to be started.
+//
+// Outputs:
+//   - err: error. ErrIdentityExported if config's conflux was exported from this host.
+func CheckIdentity(config *ConfluxConfig) error {
+	exported, err := LoadIdentityExport()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if exported.ConfluxID != config.ConfluxID {
+		return nil
+	}
+	return fmt.Errorf("%w at %s: %s must only run on the new host; run `conflux identity import` here to move it back",
+		ErrIdentityExported, exported.ExportedAt.Format(time.RFC3339), config.ConfluxID)
+}
+
+// tracerFiles returns the certificate fields of a tracer config by bundle name.
+func tracerFiles(tracer *TracerConfig) map[string]*string {
+	if tracer == nil {
+		return nil
+	}
+	return map[string]*string{
+		"ca":   &tracer.CAFile,
+		"cert": &tracer.CertFile,
+		"key":  &tracer.KeyFile,
+	}
+}
@@ -0,0 +1,174 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veil-net/conflux/secret"
+)
+
+// testBundle returns a bundle holding validConfig.
+func testBundle() *IdentityBundle {
+	return &IdentityBundle{
+		Version:    IdentityBundleVersion,
+		ExportedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		Host:       "old-host",
+		Config:     validConfig(),
+	}
+}
+
+func TestSealOpenIdentity(t *testing.T) {
+	bundle := testBundle()
+	bundle.TracerFiles = map[string]string{"ca": "ca pem"}
+	data, err := SealIdentity(bundle, "passphrase")
+	if err != nil {
+		t.Fatalf("SealIdentity: %v", err)
+	}
+	if strings.Contains(string(data), bundle.Config.Token) || strings.Contains(string(data), "ca pem") {
+		t.Error("sealed bundle contains the token or a certificate in plaintext")
+	}
+
+	opened, err := OpenIdentity(data, "passphrase")
+	if err != nil {
+		t.Fatalf("OpenIdentity: %v", err)
+	}
+	if opened.Config.ConfluxID != bundle.Config.ConfluxID || opened.Config.Token != bundle.Config.Token {
+		t.Errorf("opened config = %+v, want %+v", opened.Config, bundle.Config)
+	}
+	if opened.Host != "old-host" || !opened.ExportedAt.Equal(bundle.ExportedAt) || opened.TracerFiles["ca"] != "ca pem" {
+		t.Errorf("opened bundle = %+v, want %+v", opened, bundle)
+	}
+}
+
+func TestOpenIdentityRejects(t *testing.T) {
+	seal := func(t *testing.T, modify func(b *IdentityBundle)) []byte {
+		t.Helper()
+		bundle := testBundle()
+		modify(bundle)
+		data, err := SealIdentity(bundle, "passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		data       func(t *testing.T) []byte
+		passphrase string
+		want       string
+	}{
+		{"wrong passphrase", func(t *testing.T) []byte { return seal(t, func(b *IdentityBundle) {}) }, "other", ""},
+		{"other encrypted file", func(t *testing.T) []byte {
+			plaintext, _ := json.Marshal(testBundle())
+			data, err := secret.Seal("passphrase", plaintext, []byte("conflux-secrets"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}, "passphrase", ""},
+		{"tampered", func(t *testing.T) []byte {
+			data := seal(t, func(b *IdentityBundle) {})
+			envelope := map[string]json.RawMessage{}
+			var ciphertext []byte
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(envelope["ciphertext"], &ciphertext); err != nil {
+				t.Fatal(err)
+			}
+			ciphertext[0] ^= 1
+			envelope["ciphertext"], _ = json.Marshal(ciphertext)
+			data, _ = json.Marshal(envelope)
+			return data
+		}, "passphrase", ""},
+		{"newer version", func(t *testing.T) []byte {
+			return seal(t, func(b *IdentityBundle) { b.Version = IdentityBundleVersion + 1 })
+		}, "passphrase", "newer than this conflux supports"},
+		{"no config", func(t *testing.T) []byte { return seal(t, func(b *IdentityBundle) { b.Config = nil }) }, "passphrase", "has no config"},
+		{"invalid config", func(t *testing.T) []byte {
+			return seal(t, func(b *IdentityBundle) { b.Config.Guardian = "guardian.veilnet.app" })
+		}, "passphrase", "has an invalid config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenIdentity(tt.data(t), tt.passphrase)
+			if err == nil {
+				t.Fatal("OpenIdentity succeeded")
+			}
+			if tt.want != "" && !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("OpenIdentity error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentityExportImport(t *testing.T) {
+	dir := useConfigDir(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, []byte("ca pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := validConfig()
+	config.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CAFile: caFile}
+	if err := SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	bundle, err := ExportIdentity(context.Background(), true)
+	if err != nil {
+		t.Fatalf("ExportIdentity: %v", err)
+	}
+	if bundle.Config.Token != config.Token || bundle.Config.TokenRef != "" {
+		t.Errorf("exported token, ref = %q, %q, want the token inline", bundle.Config.Token, bundle.Config.TokenRef)
+	}
+	if bundle.TracerFiles["ca"] != "ca pem" || bundle.Config.Tracer.CAFile != "" {
+		t.Errorf("exported tracer = %+v, files %v, want the CA embedded", bundle.Config.Tracer, bundle.TracerFiles)
+	}
+
+	// The exporting host refuses to start the identity until it is imported back
+	if err := MarkIdentityExported(bundle); err != nil {
+		t.Fatalf("MarkIdentityExported: %v", err)
+	}
+	if err := CheckIdentity(config); !errors.Is(err, ErrIdentityExported) {
+		t.Errorf("CheckIdentity after export = %v, want ErrIdentityExported", err)
+	}
+	other := validConfig()
+	other.ConfluxID = "22222222-2222-2222-2222-222222222222"
+	if err := CheckIdentity(other); err != nil {
+		t.Errorf("CheckIdentity of another conflux = %v, want nil", err)
+	}
+
+	data, err := SealIdentity(bundle, "passphrase")
+	if err != nil {
+		t.Fatalf("SealIdentity: %v", err)
+	}
+	opened, err := OpenIdentity(data, "passphrase")
+	if err != nil {
+		t.Fatalf("OpenIdentity: %v", err)
+	}
+	if err := ImportIdentity(opened, ""); err != nil {
+		t.Fatalf("ImportIdentity: %v", err)
+	}
+
+	imported, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if imported.ConfluxID != config.ConfluxID || imported.Token != config.Token {
+		t.Errorf("imported config = %+v, want %+v", imported, config)
+	}
+	content, err := os.ReadFile(imported.Tracer.CAFile)
+	if err != nil || string(content) != "ca pem" {
+		t.Errorf("imported CA file %s = %q, %v, want the embedded CA", imported.Tracer.CAFile, content, err)
+	}
+	if err := CheckIdentity(imported); err != nil {
+		t.Errorf("CheckIdentity after import = %v, want nil", err)
+	}
+}
@@ -0,0 +1,93 @@
+package anchor
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// InstanceEnv selects a named instance, like the --instance flag.
+	InstanceEnv = "VEILNET_CONFLUX_INSTANCE"
+	// DefaultAnchorPort is the gRPC port of the anchor. The embedded anchor has no setting for it, so every instance
+	// uses it and only one instance's service can be installed at a time.
+	DefaultAnchorPort = 1993
+)
+
+// instanceNamePattern keeps names safe for file paths, systemd units, and launchd labels.
+var instanceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+var instance string
+
+// SetInstance selects the named instance used by the config, service, and anchor helpers; "" selects the default instance.
+//
+// Inputs:
+//   - name: string. Lowercase letters, digits, and dashes, at most 32 characters.
+//
+// Outputs:
+//   - err: error. Non-nil if the name is invalid.
+func SetInstance(name string) error {
+	if err := ValidateInstance(name); err != nil {
+		return err
+	}
+	instance = name
+	return nil
+}
+
+// ValidateInstance checks an instance name without selecting it; "" is the default instance.
+//
+// Inputs:
+//   - name: string. The instance name.
+//
+// Outputs:
+//   - err: error. Non-nil if the name is invalid.
+func ValidateInstance(name string) error {
+	if name != "" && !instanceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q: use up to 32 lowercase letters, digits, and dashes", name)
+	}
+	return nil
+}
+
+// Instance returns the selected instance name, or "" for the default instance.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - string. The instance name.
+func Instance() string {
+	return instance
+}
+
+// AnchorAddr returns the loopback gRPC address of the anchor, shared by all instances.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - string. The address, e.g. 127.0.0.1:1993.
+func AnchorAddr() string {
+	return "127.0.0.1:" + strconv.Itoa(DefaultAnchorPort)
+}
+
+// anchorPluginName returns the file name the anchor binary is extracted to, so instances do not replace each other's binary.
+//
+// Inputs:
+//   - name: string. The default name, e.g. "anchor" or "anchor.exe".
+//
+// Outputs:
+//   - string. The name with the instance inserted before any extension.
+func anchorPluginName(name string) string {
+	if instance == "" {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return name[:len(name)-len(ext)] + "-" + instance + ext
+}
+
+// instanceDir returns the per-instance subdirectory of a config or state directory.
+func instanceDir(dir string) string {
+	if instance == "" {
+		return dir
+	}
+	return filepath.Join(dir, "instances", instance)
+}
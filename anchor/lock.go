@@ -0,0 +1,61 @@
+package anchor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName is the file under the config directory that config writers lock.
+const lockFileName = "conflux.lock"
+
+const (
+	// lockTimeout is how long a config writer waits for another one before giving up.
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval is how often a held lock is retried.
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// ErrConfigLocked is returned when another process holds the config lock for longer than lockTimeout.
+var ErrConfigLocked = errors.New("config is locked by another conflux process")
+
+// lockConfigDir takes the advisory lock that serializes config writes in configDir, so concurrent commands cannot
+// lose each other's changes. The lock is released when the process exits, even if it crashes.
+//
+// Inputs:
+//   - configDir: string. The config directory; it must already exist.
+//
+// Outputs:
+//   - unlock: func(). Releases the lock.
+//   - err: error. ErrConfigLocked if the lock stays held past lockTimeout, or the error opening the lock file.
+func lockConfigDir(configDir string) (func(), error) {
+	lockFilePath := filepath.Join(configDir, lockFileName)
+	file, err := os.OpenFile(lockFilePath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%w: %s is still held after %s", ErrConfigLocked, lockFilePath, lockTimeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package anchor
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile takes an exclusive flock on file without blocking.
+//
+// Inputs:
+//   - file: *os.File. The open lock file.
+//
+// Outputs:
+//   - bool. True if the lock was taken, false if another process holds it.
+//   - err: error. Non-nil if flock fails for another reason.
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases a lock taken with tryLockFile.
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package anchor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// memfdAnchor writes the anchor binary to a sealed anonymous memory file, which has no path another process could
+// replace and disappears with its last reference.
+//
+// Inputs:
+//   - plugin: []byte. The embedded binary.
+//   - name: string. The plugin name, shown as memfd:<name> in /proc.
+//
+// Outputs:
+//   - path: string. The /proc/self/fd path to execute; it resolves in the child, which inherits the descriptor.
+//   - cleanup: func(). Closes the memory file.
+//   - err: error. Non-nil if memfd_create is unavailable or forbids executables, or /proc is not mounted.
+func memfdAnchor(plugin []byte, name string) (path string, cleanup func(), err error) {
+	flags := unix.MFD_CLOEXEC | unix.MFD_ALLOW_SEALING
+	fd, err := unix.MemfdCreate(name, flags|unix.MFD_EXEC)
+	if errors.Is(err, unix.EINVAL) {
+		// Kernels before 6.3 do not know MFD_EXEC, and their memfds are always executable
+		fd, err = unix.MemfdCreate(name, flags)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("memfd_create: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "memfd:"+name)
+
+	if _, err := file.Write(plugin); err != nil {
+		file.Close()
+		return "", nil, err
+	}
+	// Seal the content so it cannot change after it was written
+	seals := unix.F_SEAL_SEAL | unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		file.Close()
+		return "", nil, fmt.Errorf("failed to seal memfd: %w", err)
+	}
+	path = fmt.Sprintf("/proc/self/fd/%d", file.Fd())
+	if _, err := os.Stat(path); err != nil {
+		file.Close()
+		return "", nil, err
+	}
+	return path, func() { file.Close() }, nil
+}
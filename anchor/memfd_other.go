@@ -0,0 +1,19 @@
+//go:build !linux
+
+package anchor
+
+import (
+	"errors"
+)
+
+// memfdAnchor is only available on Linux; extractAnchor writes a file instead.
+//
+// Inputs:
+//   - plugin: []byte. The embedded binary.
+//   - name: string. The plugin name.
+//
+// Outputs:
+//   - err: error. Always errors.ErrUnsupported.
+func memfdAnchor(plugin []byte, name string) (path string, cleanup func(), err error) {
+	return "", nil, errors.ErrUnsupported
+}
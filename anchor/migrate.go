@@ -0,0 +1,170 @@
+package anchor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ConfigSchemaVersion is the conflux.json schema this build reads and writes.
+//
+// Versions:
+//   - 0: unversioned files; cli.Up wrote a null tracer and the token was inline.
+//   - 1: the tracer and taints are never null.
+//   - 2: the conflux token lives in secrets.json or behind conflux_token_ref instead of conflux.json.
+//
+// Older files are upgraded in memory on load and rewritten by the next save, by MigrateConfig, or when the service
+// starts (LoadMigratedConfig).
+const ConfigSchemaVersion = 2
+
+// ErrNewerSchema is returned when conflux.json was written by a newer conflux.
+var ErrNewerSchema = errors.New("config schema is newer than this conflux supports")
+
+// configMigration upgrades a raw conflux.json document by one schema version, in memory.
+type configMigration func(raw map[string]any) error
+
+// configMigrations[i] upgrades from version i to i+1.
+var configMigrations = []configMigration{
+	migrateConfigV0,
+	migrateConfigV1,
+}
+
+// upgradeConfig upgrades a raw conflux.json document to ConfigSchemaVersion in memory; nothing is written.
+// The document keeps its schema_version, so the loaded config records the version of the file on disk.
+//
+// Inputs:
+//   - configFilePath: string. The path of conflux.json, for error messages.
+//   - configFile: []byte. The content of conflux.json.
+//
+// Outputs:
+//   - []byte. The upgraded document; configFile itself if it is at the current version.
+//   - err: error. Non-nil if the document is invalid or newer than supported, or a migration step fails.
+func upgradeConfig(configFilePath string, configFile []byte) ([]byte, error) {
+	raw := make(map[string]any)
+	if err := json.Unmarshal(configFile, &raw); err != nil {
+		return nil, err
+	}
+	version := configVersion(raw)
+	if version > ConfigSchemaVersion {
+		return nil, fmt.Errorf("%w: %s has schema_version %d, this conflux supports up to %d; upgrade conflux", ErrNewerSchema, configFilePath, version, ConfigSchemaVersion)
+	}
+	if version == ConfigSchemaVersion {
+		return configFile, nil
+	}
+	for v := version; v < ConfigSchemaVersion; v++ {
+		if err := configMigrations[v](raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s from schema %d to %d: %w", configFilePath, v, v+1, err)
+		}
+	}
+	return json.Marshal(raw)
+}
+
+// configVersion returns the schema_version of a raw conflux.json document; unversioned files are version 0.
+func configVersion(raw map[string]any) int {
+	if v, ok := raw["schema_version"].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+// backupOldConfig copies conflux.json to a timestamped backup if it has an older schema, before a save rewrites it.
+//
+// Inputs:
+//   - configDir: string. The config directory.
+//
+// Outputs:
+//   - backupPath: string. The backup file; empty if there is no file or it is at the current version.
+//   - version: int. The schema version of the file.
+//   - err: error. Non-nil if the file cannot be read or the backup cannot be written.
+func backupOldConfig(configDir string) (backupPath string, version int, err error) {
+	configFilePath := filepath.Join(configDir, configFileName)
+	configFile, err := os.ReadFile(configFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	raw := make(map[string]any)
+	if err := json.Unmarshal(configFile, &raw); err != nil {
+		// An unreadable file is replaced as before; there is nothing to migrate
+		return "", 0, nil
+	}
+	version = configVersion(raw)
+	if version >= ConfigSchemaVersion {
+		return "", version, nil
+	}
+
+	// The backup may hold an inline token, so it is as private as the secrets file
+	backupPath = fmt.Sprintf("%s.v%d-%s.bak", configFilePath, version, time.Now().UTC().Format("20060102T150405"))
+	if err := writeFileAtomic(backupPath, configFile, 0600); err != nil {
+		return "", version, fmt.Errorf("failed to back up %s: %w", configFilePath, err)
+	}
+	return backupPath, version, nil
+}
+
+// MigrateConfig rewrites conflux.json at ConfigSchemaVersion under the config lock, after a backup of the old file.
+// Any save migrates the file as well; this does it explicitly, e.g. to move an inline token out of conflux.json now.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - migrated: bool. False if the file was already at the current version.
+//   - err: error. Non-nil if the lock cannot be taken or the config cannot be loaded or saved.
+func MigrateConfig() (migrated bool, err error) {
+	configDir, stateDir, unlock, err := lockConfig()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	config, err := LoadConfig()
+	if err != nil {
+		return false, err
+	}
+	if config.SchemaVersion == ConfigSchemaVersion {
+		return false, nil
+	}
+	if err := saveConfig(configDir, stateDir, config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LoadMigratedConfig migrates conflux.json to ConfigSchemaVersion if it is older and loads it. The service uses it
+// when it starts, so an upgraded conflux moves an inline token out of conflux.json once, while plain LoadConfig reads
+// stay read-only. A failed migration, e.g. on a read-only config directory, is logged and the config is upgraded in
+// memory only.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - config: *ConfluxConfig. The loaded config.
+//   - err: error. Non-nil if the config cannot be loaded.
+func LoadMigratedConfig() (*ConfluxConfig, error) {
+	// saveConfig logs the migration and the backup; a missing config is reported by LoadConfig
+	if _, err := MigrateConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		Logger.Sugar().Warnf("failed to migrate config, using it as is: %v", err)
+	}
+	return LoadConfig()
+}
+
+// migrateConfigV0 replaces a null tracer with a disabled one and null taints with an empty list.
+func migrateConfigV0(raw map[string]any) error {
+	if raw["tracer"] == nil {
+		raw["tracer"] = map[string]any{"enabled": false}
+	}
+	if raw["taints"] == nil {
+		raw["taints"] = []any{}
+	}
+	return nil
+}
+
+// migrateConfigV1 needs no change in memory: ConfluxConfig still reads an inline conflux_token, and the next save
+// moves it into the secret store.
+func migrateConfigV1(raw map[string]any) error {
+	return nil
+}
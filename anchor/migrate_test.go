@@ -0,0 +1,274 @@
+package anchor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// useConfigDir points the config and state directories at a temporary directory for the test.
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetConfigDir(dir)
+	t.Cleanup(func() { SetConfigDir("") })
+	return dir
+}
+
+// writeConfig writes a raw conflux.json into dir.
+func writeConfig(t *testing.T, dir string, raw string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, configFileName), []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRawConfig reads conflux.json from dir as a map.
+func readRawConfig(t *testing.T, dir string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := make(map[string]any)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+const v0Config = `{"conflux_id":"11111111-1111-1111-1111-111111111111","conflux_token":"abc","guardian":"https://guardian.veilnet.app","rift":false,"portal":false,"tracer":null,"taints":null}`
+
+func TestUpgradeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		check   func(t *testing.T, raw map[string]any)
+		wantErr error
+	}{
+		{
+			name: "v0 fills tracer and taints and keeps the rest",
+			raw:  v0Config,
+			check: func(t *testing.T, raw map[string]any) {
+				if tracer, ok := raw["tracer"].(map[string]any); !ok || tracer["enabled"] != false {
+					t.Errorf("tracer = %v, want a disabled tracer", raw["tracer"])
+				}
+				if taints, ok := raw["taints"].([]any); !ok || len(taints) != 0 {
+					t.Errorf("taints = %v, want an empty list", raw["taints"])
+				}
+				if raw["portal"] != false || raw["rift"] != false {
+					t.Errorf("rift/portal = %v/%v, want them unchanged", raw["rift"], raw["portal"])
+				}
+				if raw["conflux_token"] != "abc" {
+					t.Errorf("conflux_token = %v, want the inline token kept in memory", raw["conflux_token"])
+				}
+				if _, ok := raw["schema_version"]; ok {
+					t.Errorf("schema_version = %v, want the version of the file", raw["schema_version"])
+				}
+			},
+		},
+		{
+			name: "v1 keeps an existing tracer",
+			raw:  `{"schema_version":1,"conflux_token":"abc","tracer":{"enabled":true,"endpoint":"otel:4317"},"taints":["a"]}`,
+			check: func(t *testing.T, raw map[string]any) {
+				if tracer := raw["tracer"].(map[string]any); tracer["endpoint"] != "otel:4317" {
+					t.Errorf("tracer = %v, want it unchanged", tracer)
+				}
+			},
+		},
+		{
+			name:    "newer schema",
+			raw:     `{"schema_version":99}`,
+			wantErr: ErrNewerSchema,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgraded, err := upgradeConfig("conflux.json", []byte(tt.raw))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("upgradeConfig error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("upgradeConfig error = %v", err)
+			}
+			raw := make(map[string]any)
+			if err := json.Unmarshal(upgraded, &raw); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, raw)
+		})
+	}
+}
+
+func TestUpgradeConfigCurrentIsUnchanged(t *testing.T) {
+	current := []byte(`{"schema_version":2,"conflux_id":"x"}`)
+	upgraded, err := upgradeConfig("conflux.json", current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(upgraded, current) {
+		t.Errorf("upgradeConfig = %s, want the document unchanged", upgraded)
+	}
+}
+
+func TestUpgradeConfigInvalid(t *testing.T) {
+	if _, err := upgradeConfig("conflux.json", []byte(`{`)); err == nil {
+		t.Error("upgradeConfig of invalid JSON error = nil")
+	}
+}
+
+func TestLoadConfigDoesNotWrite(t *testing.T) {
+	dir := useConfigDir(t)
+	writeConfig(t, dir, v0Config)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error = %v", err)
+	}
+	if config.Token != "abc" || config.SchemaVersion != 0 || config.Portal || config.Tracer == nil || config.Taints == nil {
+		t.Errorf("LoadConfig = %+v, want the inline token, schema 0, portal kept false, and non-nil tracer and taints", config)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != v0Config {
+		t.Errorf("LoadConfig rewrote conflux.json:\n%s", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("LoadConfig left %d files in the config directory, want only conflux.json", len(entries))
+	}
+}
+
+func TestLoadConfigNewerSchema(t *testing.T) {
+	dir := useConfigDir(t)
+	writeConfig(t, dir, `{"schema_version":99,"conflux_id":"x"}`)
+	if _, err := LoadConfig(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("LoadConfig error = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir := useConfigDir(t)
+	writeConfig(t, dir, v0Config)
+
+	migrated, err := MigrateConfig()
+	if err != nil || !migrated {
+		t.Fatalf("MigrateConfig = %v, %v; want true, nil", migrated, err)
+	}
+
+	raw := readRawConfig(t, dir)
+	if raw["schema_version"] != float64(ConfigSchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", raw["schema_version"], ConfigSchemaVersion)
+	}
+	if _, ok := raw["conflux_token"]; ok {
+		t.Error("conflux.json still holds the token")
+	}
+	if raw["portal"] != false {
+		t.Errorf("portal = %v, want the saved false", raw["portal"])
+	}
+
+	// The backup keeps the old file as it was, readable only by the owner
+	backups, err := filepath.Glob(filepath.Join(dir, configFileName+".v0-*.bak"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups = %v, %v; want one", backups, err)
+	}
+	backup, err := os.ReadFile(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != v0Config {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+	if info, err := os.Stat(backups[0]); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("backup has mode %04o, want 0600", info.Mode().Perm())
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig after migration error = %v", err)
+	}
+	if config.Token != "abc" || config.SchemaVersion != ConfigSchemaVersion {
+		t.Errorf("LoadConfig = token %q schema %d, want the token from secrets.json at schema %d", config.Token, config.SchemaVersion, ConfigSchemaVersion)
+	}
+
+	migrated, err = MigrateConfig()
+	if err != nil || migrated {
+		t.Errorf("second MigrateConfig = %v, %v; want false, nil", migrated, err)
+	}
+}
+
+func TestUpdateConfigMigrates(t *testing.T) {
+	dir := useConfigDir(t)
+	writeConfig(t, dir, v0Config)
+
+	_, err := UpdateConfig(func(config *ConfluxConfig) error {
+		config.Taints = append(config.Taints, "prod")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateConfig error = %v", err)
+	}
+	raw := readRawConfig(t, dir)
+	if raw["schema_version"] != float64(ConfigSchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", raw["schema_version"], ConfigSchemaVersion)
+	}
+	if taints, _ := raw["taints"].([]any); len(taints) != 1 || taints[0] != "prod" {
+		t.Errorf("taints = %v, want [prod]", raw["taints"])
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, configFileName+".v0-*.bak")); len(backups) != 1 {
+		t.Errorf("backups = %v, want one", backups)
+	}
+}
+
+func TestLoadMigratedConfig(t *testing.T) {
+	dir := useConfigDir(t)
+	writeConfig(t, dir, v0Config)
+
+	config, err := LoadMigratedConfig()
+	if err != nil {
+		t.Fatalf("LoadMigratedConfig error = %v", err)
+	}
+	if config.Token != "abc" || config.SchemaVersion != ConfigSchemaVersion {
+		t.Errorf("LoadMigratedConfig = token %q schema %d, want %q at schema %d", config.Token, config.SchemaVersion, "abc", ConfigSchemaVersion)
+	}
+	if _, ok := readRawConfig(t, dir)["conflux_token"]; ok {
+		t.Error("conflux.json still holds the token after the service start migration")
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, configFileName+".v0-*.bak")); len(backups) != 1 {
+		t.Errorf("backups = %v, want one", backups)
+	}
+
+	// A second start finds the file current and leaves it alone
+	before, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMigratedConfig(); err != nil {
+		t.Fatalf("second LoadMigratedConfig error = %v", err)
+	}
+	after, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil || !bytes.Equal(before, after) {
+		t.Errorf("second LoadMigratedConfig rewrote conflux.json: %v", err)
+	}
+}
+
+func TestLoadMigratedConfigMissing(t *testing.T) {
+	useConfigDir(t)
+	if _, err := LoadMigratedConfig(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadMigratedConfig without a config error = %v, want fs.ErrNotExist", err)
+	}
+}
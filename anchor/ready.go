@@ -0,0 +1,190 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	pb "github.com/veil-net/conflux/proto"
+)
+
+// AnchorReadyTimeoutEnv overrides how long the anchor subprocess may take to start, e.g. 2m on slow boards.
+const AnchorReadyTimeoutEnv = "VEILNET_ANCHOR_READY_TIMEOUT"
+
+// ErrAnchorExited is returned when the anchor subprocess exits before its gRPC server is ready.
+var ErrAnchorExited = errors.New("anchor subprocess exited during startup")
+
+// ErrAnchorPortInUse is returned when something already listens on the anchor gRPC address, usually the anchor of
+// another conflux instance.
+var ErrAnchorPortInUse = errors.New("anchor gRPC address is already in use")
+
+// ReadyOptions controls how WaitReady polls the anchor gRPC server.
+type ReadyOptions struct {
+	// Timeout bounds the whole wait.
+	Timeout time.Duration
+	// InitialBackoff is the delay after the first failed attempt; it doubles after each attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultReadyOptions returns the options used to start the anchor: a 30s timeout, or VEILNET_ANCHOR_READY_TIMEOUT,
+// and a backoff from 25ms to 250ms.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - ReadyOptions. The options.
+func DefaultReadyOptions() ReadyOptions {
+	options := ReadyOptions{
+		Timeout:        30 * time.Second,
+		InitialBackoff: 25 * time.Millisecond,
+		MaxBackoff:     250 * time.Millisecond,
+	}
+	if value := os.Getenv(AnchorReadyTimeoutEnv); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout > 0 {
+			options.Timeout = timeout
+		} else {
+			Logger.Sugar().Warnf("ignoring %s=%q, expected a duration such as 1m", AnchorReadyTimeoutEnv, value)
+		}
+	}
+	return options
+}
+
+// readyAfter waits between WaitReady attempts; tests replace it to observe the backoff.
+var readyAfter = time.After
+
+// WaitReady waits until the anchor gRPC server of the selected instance accepts connections, polling with
+// exponential backoff, and fails fast if the subprocess exits first.
+//
+// Inputs:
+//   - ctx: context.Context. Cancels the wait.
+//   - subprocess: *exec.Cmd. The anchor subprocess started by NewAnchor.
+//   - options: ReadyOptions. Timeout and backoff.
+//
+// Outputs:
+//   - err: error. ErrAnchorExited if the subprocess exits, or an error if the timeout passes or ctx is done.
+func WaitReady(ctx context.Context, subprocess *exec.Cmd, options ReadyOptions) error {
+	return waitReady(ctx, AnchorAddr(), subprocess, Exited(subprocess), options)
+}
+
+// waitReady implements WaitReady for the server at addr and a subprocess that has exited once exited is closed.
+func waitReady(ctx context.Context, addr string, subprocess *exec.Cmd, exited <-chan struct{}, options ReadyOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	delay := options.InitialBackoff
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("%w: %s", ErrAnchorExited, subprocess.ProcessState)
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("anchor gRPC server on %s was not ready within %s: %v", addr, options.Timeout, err)
+			}
+			return ctx.Err()
+		case <-readyAfter(delay):
+		}
+		delay = min(delay*2, options.MaxBackoff)
+	}
+}
+
+// NewReadyAnchor starts the anchor subprocess, waits for its gRPC server, and connects a client to it.
+//
+// Inputs:
+//   - ctx: context.Context. Cancels the wait.
+//   - options: ReadyOptions. Timeout and backoff, usually DefaultReadyOptions().
+//
+// Outputs:
+//   - *exec.Cmd. The running anchor subprocess.
+//   - pb.AnchorClient. The gRPC client.
+//   - err: error. ErrAnchorPortInUse if another anchor runs, or non-nil if the subprocess cannot be started or does
+//     not become ready; it is killed in that case.
+func NewReadyAnchor(ctx context.Context, options ReadyOptions) (*exec.Cmd, pb.AnchorClient, error) {
+	// WaitReady would otherwise take a running anchor for the new one
+	if err := checkAnchorAddrFree(); err != nil {
+		return nil, nil, err
+	}
+	subprocess, err := NewAnchor()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := WaitReady(ctx, subprocess, options); err != nil {
+		subprocess.Process.Kill()
+		return nil, nil, err
+	}
+	client, err := NewAnchorClient()
+	if err != nil {
+		subprocess.Process.Kill()
+		return nil, nil, err
+	}
+	return subprocess, client, nil
+}
+
+// checkAnchorAddrFree checks that nothing listens on the anchor gRPC address.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - err: error. ErrAnchorPortInUse, naming the address, if it is taken.
+func checkAnchorAddrFree() error {
+	addr := AnchorAddr()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%w: %s: stop the conflux or instance running there first, instances share the anchor port: %v",
+			ErrAnchorPortInUse, addr, err)
+	}
+	return listener.Close()
+}
+
+var (
+	exitsMu sync.Mutex
+	exits   = map[*exec.Cmd]chan struct{}{}
+)
+
+// watchExit reaps a started subprocess in the background so Exited can report when it ends, then runs cleanup.
+func watchExit(cmd *exec.Cmd, cleanup func()) {
+	done := make(chan struct{})
+	exitsMu.Lock()
+	exits[cmd] = done
+	exitsMu.Unlock()
+	go func() {
+		cmd.Wait()
+		cleanup()
+		exitsMu.Lock()
+		delete(exits, cmd)
+		exitsMu.Unlock()
+		close(done)
+	}()
+}
+
+// Exited returns a channel that is closed once an anchor subprocess started by NewAnchor has exited; its
+// ProcessState is set by then. NewAnchor already waits on the subprocess, so callers must not call Wait.
+//
+// Inputs:
+//   - cmd: *exec.Cmd. The subprocess.
+//
+// Outputs:
+//   - <-chan struct{}. Closed on exit.
+func Exited(cmd *exec.Cmd) <-chan struct{} {
+	exitsMu.Lock()
+	defer exitsMu.Unlock()
+	if done, ok := exits[cmd]; ok {
+		return done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
@@ -0,0 +1,125 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testReadyOptions polls quickly with the default backoff shape.
+var testReadyOptions = ReadyOptions{Timeout: 5 * time.Second, InitialBackoff: 25 * time.Millisecond, MaxBackoff: 250 * time.Millisecond}
+
+// freeAddr returns a loopback address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func listen(t *testing.T, addr string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+}
+
+func TestWaitReadyListening(t *testing.T) {
+	addr := freeAddr(t)
+	listen(t, addr)
+	if err := waitReady(context.Background(), addr, &exec.Cmd{}, make(chan struct{}), testReadyOptions); err != nil {
+		t.Errorf("waitReady = %v, want nil", err)
+	}
+}
+
+func TestWaitReadyBackoff(t *testing.T) {
+	addr := freeAddr(t)
+	var delays []time.Duration
+	previous := readyAfter
+	readyAfter = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		// The server comes up after six failed attempts
+		if len(delays) == 6 {
+			listen(t, addr)
+		}
+		fired := make(chan time.Time, 1)
+		fired <- time.Now()
+		return fired
+	}
+	t.Cleanup(func() { readyAfter = previous })
+
+	if err := waitReady(context.Background(), addr, &exec.Cmd{}, make(chan struct{}), testReadyOptions); err != nil {
+		t.Fatalf("waitReady = %v, want nil", err)
+	}
+	want := []time.Duration{25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+		250 * time.Millisecond, 250 * time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Errorf("delays = %v, want %v", delays, want)
+	}
+}
+
+func TestWaitReadyFailsFastOnExit(t *testing.T) {
+	exited := make(chan struct{})
+	close(exited)
+	options := testReadyOptions
+	options.Timeout = time.Minute
+
+	start := time.Now()
+	err := waitReady(context.Background(), freeAddr(t), &exec.Cmd{}, exited, options)
+	if !errors.Is(err, ErrAnchorExited) {
+		t.Errorf("waitReady = %v, want ErrAnchorExited", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waitReady took %s after the subprocess exited", elapsed)
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	options := testReadyOptions
+	options.Timeout = 100 * time.Millisecond
+	err := waitReady(context.Background(), freeAddr(t), &exec.Cmd{}, make(chan struct{}), options)
+	if err == nil || !strings.Contains(err.Error(), "was not ready within 100ms") {
+		t.Errorf("waitReady = %v, want the timeout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := waitReady(ctx, freeAddr(t), &exec.Cmd{}, make(chan struct{}), testReadyOptions); !errors.Is(err, context.Canceled) {
+		t.Errorf("waitReady with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestDefaultReadyOptionsTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 30 * time.Second},
+		{"2m", 2 * time.Minute},
+		{"90s", 90 * time.Second},
+		{"soon", 30 * time.Second},
+		{"-1s", 30 * time.Second},
+		{"0", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Setenv(AnchorReadyTimeoutEnv, tt.value)
+		options := DefaultReadyOptions()
+		if options.Timeout != tt.want {
+			t.Errorf("%s=%q: timeout = %s, want %s", AnchorReadyTimeoutEnv, tt.value, options.Timeout, tt.want)
+		}
+		if options.InitialBackoff != 25*time.Millisecond || options.MaxBackoff != 250*time.Millisecond {
+			t.Errorf("%s=%q: backoff = %s to %s, want 25ms to 250ms", AnchorReadyTimeoutEnv, tt.value, options.InitialBackoff, options.MaxBackoff)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/veil-net/conflux/secret"
+)
+
+const (
+	// configFileName is the file under the config directory holding non-secret settings.
+	configFileName = "conflux.json"
+	// secretsFileName is the file under the config directory holding the conflux credentials.
+	secretsFileName = "secrets.json"
+)
+
+// ConfluxSecrets holds the credentials split out of conflux.json; it is the default token store, private to the owner
+// but not encrypted, used when the config has no TokenRef.
+type ConfluxSecrets struct {
+	Token string `json:"conflux_token"`
+}
+
+// ErrInsecureSecrets is returned when the secrets file is accessible to other users.
+var ErrInsecureSecrets = errors.New("secrets file is accessible to other users")
+
+// loadSecrets reads the secrets file, refusing it if other users can access it.
+// Group access is tightened with a warning; world access is refused since the token must be treated as leaked.
+//
+// Inputs:
+//   - stateDir: string. The state directory.
+//
+// Outputs:
+//   - secrets: *ConfluxSecrets. The stored credentials.
+//   - err: error. Non-nil if the file is missing, unreadable, or world-accessible.
+func loadSecrets(stateDir string) (*ConfluxSecrets, error) {
+	secretsFilePath := filepath.Join(stateDir, secretsFileName)
+	info, err := os.Stat(secretsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Windows relies on the ProgramData ACLs instead of mode bits
+	if runtime.GOOS != "windows" {
+		mode := info.Mode().Perm()
+		if mode&0007 != 0 {
+			return nil, fmt.Errorf("%w: %s has mode %04o, run `chmod 600 %s` and re-register to rotate the conflux token", ErrInsecureSecrets, secretsFilePath, mode, secretsFilePath)
+		}
+		if mode&0070 != 0 {
+			Logger.Sugar().Warnf("%s has mode %04o, tightening it to 0600", secretsFilePath, mode)
+			if err := os.Chmod(secretsFilePath, 0600); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	secretsFile, err := os.ReadFile(secretsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	secrets := &ConfluxSecrets{}
+	if err := json.Unmarshal(secretsFile, secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// warnInlineToken warns when conflux.json still holds the conflux token and other local users can read it; such a file
+// predates schema 2, and the service moves the token out when it next starts.
+//
+// Inputs:
+//   - configFilePath: string. The conflux.json path.
+//
+// Outputs: none.
+func warnInlineToken(configFilePath string) {
+	// Windows relies on the ProgramData ACLs instead of mode bits
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(configFilePath)
+	if err != nil {
+		return
+	}
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		Logger.Sugar().Warnf("%s holds the conflux token and has mode %04o, so other local users can read it; "+
+			"run `conflux config migrate` to move the token out and re-register to rotate it", configFilePath, mode)
+	}
+}
+
+// saveSecrets writes the secrets file readable only by the current user.
+//
+// Inputs:
+//   - stateDir: string. The state directory; it must already exist.
+//   - secrets: *ConfluxSecrets. The credentials to store.
+//
+// Outputs:
+//   - err: error. Non-nil if the file cannot be written.
+func saveSecrets(stateDir string, secrets *ConfluxSecrets) error {
+	secretsFile, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(stateDir, secretsFileName), secretsFile, 0600)
+}
+
+// deleteSecrets removes the secrets file; a missing file is not an error.
+//
+// Inputs:
+//   - stateDir: string. The state directory.
+//
+// Outputs:
+//   - err: error. Non-nil if the file exists but cannot be removed.
+func deleteSecrets(stateDir string) error {
+	err := os.Remove(filepath.Join(stateDir, secretsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ensureConfigDir creates a config or state directory and restricts it to the current user.
+//
+// Inputs:
+//   - configDir: string. The directory.
+//
+// Outputs:
+//   - err: error. Non-nil if the directory cannot be created or its mode changed.
+func ensureConfigDir(configDir string) error {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	// MkdirAll leaves an existing directory untouched, so tighten it explicitly
+	return os.Chmod(configDir, 0700)
+}
+
+// storeToken writes the conflux token to TokenRef, or to the secrets file when there is no reference.
+// A referenced backend is only written when its value differs, so read-only stores keep working; once written, the
+// reference is recorded as OwnedTokenRef.
+//
+// Inputs:
+//   - stateDir: string. The state directory; it must already exist.
+//   - config: *ConfluxConfig. The config holding Token and TokenRef.
+//
+// Outputs:
+//   - err: error. Non-nil if the token cannot be stored.
+func storeToken(stateDir string, config *ConfluxConfig) error {
+	if config.TokenRef == "" {
+		return saveSecrets(stateDir, &ConfluxSecrets{Token: config.Token})
+	}
+
+	ctx := context.Background()
+	if current, err := secret.Resolve(ctx, config.TokenRef); err != nil || current != config.Token {
+		if err := secret.Store(ctx, config.TokenRef, config.Token); err != nil {
+			return err
+		}
+		config.OwnedTokenRef = config.TokenRef
+	}
+	// A token moved to a backend must not linger in the secrets file
+	return deleteSecrets(stateDir)
+}
+
+// deleteTokenRef deletes the token behind the TokenRef recorded in conflux.json if conflux stored it there; a
+// reference the operator provisioned, such as a `--token secret://...` given to up, is left alone.
+//
+// Inputs:
+//   - configDir: string. The config directory.
+//
+// Outputs:
+//   - err: error. Non-nil if conflux.json is unreadable or the backend fails.
+func deleteTokenRef(configDir string) error {
+	configFile, err := os.ReadFile(filepath.Join(configDir, configFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var config ConfluxConfig
+	if err := json.Unmarshal(configFile, &config); err != nil {
+		return err
+	}
+	if config.TokenRef == "" || config.TokenRef != config.OwnedTokenRef {
+		return nil
+	}
+	return secret.Delete(context.Background(), config.TokenRef)
+}
+
+// materializeSecret returns value unchanged unless it is a secret:// reference, in which case the secret is written
+// to a private file under the config directory and that path is returned; the anchor only accepts file paths.
+//
+// Inputs:
+//   - ctx: context.Context. Request context for the secret backend.
+//   - name: string. The file name under <config dir>/runtime.
+//   - value: string. A path or a secret:// reference.
+//
+// Outputs:
+//   - string. A file path.
+//   - err: error. Non-nil if the secret cannot be resolved or written.
+func materializeSecret(ctx context.Context, name string, value string) (string, error) {
+	if !secret.IsRef(value) {
+		return value, nil
+	}
+	content, err := secret.Resolve(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	runtimeDir := filepath.Join(stateDir, "runtime")
+	if err := ensureConfigDir(runtimeDir); err != nil {
+		return "", err
+	}
+	path := filepath.Join(runtimeDir, name)
+	if err := writeFileAtomic(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeConfigFile writes the settings in config to conflux.json at the current schema version, leaving the secrets out.
+//
+// Inputs:
+//   - configDir: string. The config directory; it must already exist.
+//   - config: *ConfluxConfig. The config to write.
+//
+// Outputs:
+//   - err: error. Non-nil if the file cannot be written.
+func writeConfigFile(configDir string, config *ConfluxConfig) error {
+	settings := *config
+	settings.Token = ""
+	settings.SchemaVersion = ConfigSchemaVersion
+	settings.normalize()
+	// The JWT is a short-lived registration credential and is not kept
+	if settings.IDP != nil {
+		idp := *settings.IDP
+		idp.JWT = ""
+		settings.IDP = &idp
+	}
+	configFile, err := json.Marshal(&settings)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(configDir, configFileName), configFile, 0644)
+}
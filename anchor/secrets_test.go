@@ -0,0 +1,143 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/veil-net/conflux/secret"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDeleteConfigTokenRef(t *testing.T) {
+	tests := []struct {
+		name string
+		// owned marks ref as given with --token-store; otherwise it is an operator's --token reference
+		owned   bool
+		prefill bool
+		deleted bool
+	}{
+		{"operator reference is kept", false, true, false},
+		{"reference conflux wrote is deleted", true, false, true},
+		{"token-store reference that already held the token is deleted", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigDir(t)
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			ref := secret.Ref{Backend: "file", Path: filepath.ToSlash(tokenFile)}.String()
+			config := validConfig()
+			if tt.prefill {
+				if err := os.WriteFile(tokenFile, []byte(config.Token), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config.TokenRef = ref
+			if tt.owned {
+				config.OwnedTokenRef = ref
+			}
+			if err := SaveConfig(config); err != nil {
+				t.Fatalf("SaveConfig: %v", err)
+			}
+			if err := DeleteConfig(); err != nil {
+				t.Fatalf("DeleteConfig: %v", err)
+			}
+
+			_, err := os.Stat(tokenFile)
+			if deleted := errors.Is(err, fs.ErrNotExist); deleted != tt.deleted {
+				t.Errorf("token file deleted = %t, want %t", deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestStoreTokenRecordsWrittenReference(t *testing.T) {
+	dir := useConfigDir(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	ref := secret.Ref{Backend: "file", Path: filepath.ToSlash(tokenFile)}.String()
+
+	// Setting a reference that does not hold the token yet makes conflux write it, so the reference is conflux's
+	config := validConfig()
+	config.TokenRef = ref
+	if err := SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	if got := readRawConfig(t, dir)["conflux_token_ref_owned"]; got != ref {
+		t.Errorf("conflux_token_ref_owned = %v, want %q", got, ref)
+	}
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if loaded.Token != config.Token || loaded.OwnedTokenRef != ref {
+		t.Errorf("loaded token, owned ref = %q, %q, want %q, %q", loaded.Token, loaded.OwnedTokenRef, config.Token, ref)
+	}
+}
+
+func TestLoadConfigWarnsOnReadableInlineToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows relies on ACLs instead of mode bits")
+	}
+	tests := []struct {
+		name string
+		mode os.FileMode
+		warn bool
+	}{
+		{"world readable", 0644, true},
+		{"group readable", 0640, true},
+		{"private", 0600, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useConfigDir(t)
+			writeConfig(t, dir, v0Config)
+			if err := os.Chmod(filepath.Join(dir, configFileName), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			core, logs := observer.New(zap.WarnLevel)
+			previous := Logger
+			Logger = zap.New(core)
+			t.Cleanup(func() { Logger = previous })
+
+			if _, err := LoadConfig(); err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			warned := logs.FilterMessageSnippet("holds the conflux token").Len() > 0
+			if warned != tt.warn {
+				t.Errorf("warned = %t, want %t", warned, tt.warn)
+			}
+		})
+	}
+}
+
+func TestMaterializeSecret(t *testing.T) {
+	dir := useConfigDir(t)
+	if got, err := materializeSecret(context.Background(), "ca.pem", "/etc/ssl/ca.pem"); err != nil || got != "/etc/ssl/ca.pem" {
+		t.Errorf("materializeSecret of a path = %q, %v; want it unchanged", got, err)
+	}
+
+	source := filepath.Join(t.TempDir(), "ca")
+	if err := os.WriteFile(source, []byte("ca pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ref := secret.Ref{Backend: "file", Path: filepath.ToSlash(source)}.String()
+	path, err := materializeSecret(context.Background(), "ca.pem", ref)
+	if err != nil {
+		t.Fatalf("materializeSecret: %v", err)
+	}
+	if filepath.Dir(path) != filepath.Join(dir, "runtime") {
+		t.Errorf("materialized at %s, want under %s", path, filepath.Join(dir, "runtime"))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "ca pem" {
+		t.Errorf("materialized content = %q, %v; want %q", content, err, "ca pem")
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("materialized file has mode %04o, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,122 @@
+package anchor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionFileName is the file under the config directory holding the cached user session.
+const sessionFileName = "session.json"
+
+// Session is a cached Guardian user session used by management commands.
+type Session struct {
+	Guardian     string    `json:"guardian"`
+	Email        string    `json:"email"`
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the session has expired or will expire within leeway.
+//
+// Inputs:
+//   - leeway: time.Duration. Safety margin before the actual expiry.
+//
+// Outputs:
+//   - bool. True if the access token should no longer be used.
+func (s *Session) Expired(leeway time.Duration) bool {
+	if s.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(leeway).After(s.ExpiresAt)
+}
+
+// TokenExpiry returns the "exp" claim of a JWT, or the zero time if it cannot be read.
+//
+// Inputs:
+//   - token: string. The JWT.
+//
+// Outputs:
+//   - time.Time. The expiry time.
+func TokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// LoadSession loads the cached user session.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - session: *Session. The cached session.
+//   - err: error. Non-nil if there is no session or it cannot be read.
+func LoadSession() (*Session, error) {
+	stateDir, err := baseStateDir()
+	if err != nil {
+		return nil, err
+	}
+	sessionFile, err := os.ReadFile(filepath.Join(stateDir, sessionFileName))
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{}
+	if err := json.Unmarshal(sessionFile, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// SaveSession writes the user session, readable only by the current user.
+//
+// Inputs:
+//   - session: *Session. The session to cache.
+//
+// Outputs:
+//   - err: error. Non-nil if the file cannot be written.
+func SaveSession(session *Session) error {
+	stateDir, err := baseStateDir()
+	if err != nil {
+		return err
+	}
+	sessionFile, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return err
+	}
+	// A crash while writing must not truncate the session
+	return writeFileAtomic(filepath.Join(stateDir, sessionFileName), sessionFile, 0600)
+}
+
+// DeleteSession removes the cached user session.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - err: error. Non-nil if the file cannot be removed.
+func DeleteSession() error {
+	stateDir, err := baseStateDir()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(stateDir, sessionFileName))
+}
@@ -0,0 +1,67 @@
+package anchor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// anchorStatusFileName is the file under <state dir>/runtime where the service records the anchor subprocess state.
+const anchorStatusFileName = "anchor-status.json"
+
+// AnchorStatus is the supervision state of the anchor subprocess, written by the service for other commands to read.
+type AnchorStatus struct {
+	PID        int       `json:"pid"`
+	StartedAt  time.Time `json:"started_at"`
+	Restarts   int       `json:"restarts"`
+	LastExit   string    `json:"last_exit,omitempty"`
+	LastExitAt time.Time `json:"last_exit_at,omitzero"`
+	CrashLoop  bool      `json:"crash_loop"`
+}
+
+// SaveAnchorStatus records the anchor subprocess state of the selected instance.
+//
+// Inputs:
+//   - status: *AnchorStatus. The state.
+//
+// Outputs:
+//   - err: error. Non-nil if the file cannot be written.
+func SaveAnchorStatus(status *AnchorStatus) error {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return err
+	}
+	runtimeDir := filepath.Join(stateDir, "runtime")
+	if err := ensureConfigDir(runtimeDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(runtimeDir, anchorStatusFileName), data, 0600)
+}
+
+// LoadAnchorStatus returns the anchor subprocess state last recorded by the service.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - *AnchorStatus. The state.
+//   - err: error. fs.ErrNotExist if the service has not run.
+func LoadAnchorStatus() (*AnchorStatus, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, "runtime", anchorStatusFileName))
+	if err != nil {
+		return nil, err
+	}
+	status := &AnchorStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -2,58 +2,79 @@
 package anchor
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
-	"time"
 
+	"github.com/veil-net/conflux/guardian"
+	"github.com/veil-net/conflux/logger"
 	pb "github.com/veil-net/conflux/proto"
+	"github.com/veil-net/conflux/secret"
 )
 
-// TracerConfig holds OTLP/tracing settings (enabled, endpoint, TLS, certs).
+// Logger is the package logger, used for permission warnings and config migrations.
+var Logger = logger.Logger
+
+// TracerConfig holds OTLP/tracing settings (enabled, endpoint, TLS, certs); the file fields accept secret:// references.
 type TracerConfig struct {
-	Enabled  bool   `json:"enabled" validate:"required"`
-	Endpoint string `json:"endpoint" validate:"required"`
-	UseTLS   bool   `json:"use_tls" validate:"required"`
-	Insecure bool   `json:"insecure" validate:"required"`
-	CAFile   string `json:"ca_file" validate:"required"`
-	CertFile string `json:"cert_file" validate:"required"`
-	KeyFile  string `json:"key_file" validate:"required"`
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint" validate:"required_if=Enabled,endpoint"`
+	UseTLS   bool   `json:"use_tls"`
+	Insecure bool   `json:"insecure"`
+	CAFile   string `json:"ca_file"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
 }
 
+// IDPConfig holds the identity provider the conflux verifies JWTs against; the JWT itself is only sent at registration.
 type IDPConfig struct {
-	JWT string `json:"jwt" validate:"required"`
-	JWKS_url string `json:"jwks_url" validate:"required"`
+	JWT      string `json:"jwt"`
+	JWKS_url string `json:"jwks_url" validate:"required,url"`
 	Audience string `json:"audience" validate:"required"`
-	Issuer string `json:"issuer" validate:"required"`
+	Issuer   string `json:"issuer" validate:"required"`
 }
 
-// ConfluxConfig holds conflux runtime config (ID, token, guardian, rift/portal, IP, taints, tracer).
+// ConfluxConfig holds conflux runtime config (ID, token, guardian, rift/portal, IP, taints, tracer, region/veil pins).
+// The token is kept out of conflux.json, in secrets.json or the secret:// reference TokenRef. OwnedTokenRef is the
+// reference conflux stored the token in itself; only that one is deleted with the config, never one the operator
+// provisioned.
 type ConfluxConfig struct {
-	ConfluxID string        `json:"conflux_id" validate:"required"`
-	Token     string        `json:"conflux_token" validate:"required"`
-	Guardian  string        `json:"guardian" validate:"required"`
-	Rift      bool          `json:"rift" validate:"required"`
-	Portal    bool          `json:"portal" validate:"required"`
-	IP        string        `json:"ip" validate:"required"`
-	Taints    []string      `json:"taints"`
-	Tracer    *TracerConfig `json:"tracer"`
+	SchemaVersion int           `json:"schema_version"`
+	ConfluxID     string        `json:"conflux_id" validate:"required"`
+	Token         string        `json:"conflux_token,omitempty" validate:"required"`
+	TokenRef      string        `json:"conflux_token_ref,omitempty" validate:"secret_ref"`
+	OwnedTokenRef string        `json:"conflux_token_ref_owned,omitempty"`
+	Guardian      string        `json:"guardian" validate:"required,url"`
+	Rift          bool          `json:"rift"`
+	Portal        bool          `json:"portal"`
+	IP            string        `json:"ip" validate:"ip_or_cidr"`
+	Taints        []string      `json:"taints"`
+	Tracer        *TracerConfig `json:"tracer"`
+	Region        string        `json:"region,omitempty"`
+	Veil          string        `json:"veil,omitempty"`
+	IDP           *IDPConfig    `json:"idp,omitempty"`
+}
+
+// normalize applies the invariants of the current schema in memory: tracer and taints are never null.
+func (c *ConfluxConfig) normalize() {
+	if c.Tracer == nil {
+		c.Tracer = &TracerConfig{}
+	}
+	if c.Taints == nil {
+		c.Taints = []string{}
+	}
 }
 
 // ResgitrationRequest is the request payload for conflux registration (token, guardian, tag, JWT/JWKS, etc.).
 type ResgitrationRequest struct {
 	RegistrationToken string `json:"registration_token" validate:"required"`
-	Guardian          string `json:"guardian" validate:"required"`
+	Guardian          string `json:"guardian" validate:"required,url"`
 	Tag               string `json:"tag"`
 	JWT               string `json:"jwt"`
-	JWKS_url          string `json:"jwks_url"`
+	JWKS_url          string `json:"jwks_url" validate:"url"`
 	Audience          string `json:"audience"`
 	Issuer            string `json:"issuer"`
 }
@@ -64,192 +85,259 @@ type RegistrationResponse struct {
 	Token     string `json:"token" validate:"required"`
 }
 
-// GetConfigDir returns the OS-specific config directory for conflux.
+// LoadConfig loads ConfluxConfig from the config file and the conflux token from TokenRef or the secrets file.
+// Files with an older schema_version are upgraded in memory only; nothing is written, see MigrateConfig.
 //
 // Inputs: none.
 //
 // Outputs:
-//   - configDir: string. The config directory path.
-//   - err: error. Non-nil if the directory cannot be determined.
-func GetConfigDir() (string, error) {
-	var configDir string
+//   - config: *ConfluxConfig. The loaded config.
+//   - err: error. Non-nil if a file is missing or invalid, the schema is newer than supported, or the secrets file is accessible to other users.
+func LoadConfig() (*ConfluxConfig, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	configFilePath := filepath.Join(configDir, configFileName)
+	configFile, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	configFile, err = upgradeConfig(configFilePath, configFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &ConfluxConfig{}
+	err = json.Unmarshal(configFile, config)
+	if err != nil {
+		return nil, err
+	}
+	config.normalize()
+
+	// Files before schema 2 keep the token inline until the next save moves it
+	if config.SchemaVersion < 2 && config.Token != "" {
+		warnInlineToken(configFilePath)
+		return config, nil
+	}
 
-	switch runtime.GOOS {
-	case "windows":
-		programData := os.Getenv("ProgramData")
-		if programData == "" {
-			programData = "C:\\ProgramData"
+	if config.TokenRef != "" {
+		config.Token, err = secret.Resolve(context.Background(), config.TokenRef)
+		if err != nil {
+			return nil, err
 		}
-		configDir = filepath.Join(programData, "conflux")
-	case "darwin":
-		configDir = "/var/root/Library/Application Support/conflux"
-	default:
-		configDir = "/root/.config/conflux"
+		return config, nil
 	}
 
-	return configDir, nil
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := loadSecrets(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	config.Token = secrets.Token
+	return config, nil
 }
 
-// LoadConfig loads ConfluxConfig from the config file.
+// ConfigFiles returns the files LoadConfig reads, for watching them for changes: conflux.json and the secrets file.
 //
 // Inputs: none.
 //
 // Outputs:
-//   - config: *ConfluxConfig. The loaded config.
-//   - err: error. Non-nil if the file is missing or invalid.
-func LoadConfig() (*ConfluxConfig, error) {
+//   - []string. The file paths; the secrets file may not exist when the token is behind a reference.
+//   - err: error. Non-nil if the directories cannot be determined.
+func ConfigFiles() ([]string, error) {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return nil, err
 	}
-	configFilePath := filepath.Join(configDir, "conflux.json")
-	configFile, err := os.ReadFile(configFilePath)
+	stateDir, err := GetStateDir()
 	if err != nil {
 		return nil, err
 	}
-	config := &ConfluxConfig{}
-	err = json.Unmarshal(configFile, config)
-	if err != nil {
-		return nil, err
-	}
-	return config, nil
+	return []string{filepath.Join(configDir, configFileName), filepath.Join(stateDir, secretsFileName)}, nil
 }
 
-// SaveConfig writes ConfluxConfig to the config file.
+// SaveConfig writes ConfluxConfig to the config file and the conflux token to TokenRef or the secrets file.
+// Files are replaced atomically under the config lock; use UpdateConfig to change a config that was loaded.
 //
 // Inputs:
 //   - config: *ConfluxConfig. The conflux config to write.
 //
 // Outputs:
-//   - err: error. Non-nil if the file cannot be written.
+//   - err: error. Non-nil if the lock cannot be taken or a file cannot be written.
 func SaveConfig(config *ConfluxConfig) error {
-	configDir, err := GetConfigDir()
+	configDir, stateDir, unlock, err := lockConfig()
 	if err != nil {
 		return err
 	}
-	configFilePath := filepath.Join(configDir, "conflux.json")
-	configFile, err := json.Marshal(config)
+	defer unlock()
+	return saveConfig(configDir, stateDir, config)
+}
+
+// UpdateConfig loads the config, passes it to update, and saves the result, holding the config lock throughout so
+// concurrent commands cannot lose each other's changes.
+//
+// Inputs:
+//   - update: func(*ConfluxConfig) error. Changes the config in place; an error aborts without saving.
+//
+// Outputs:
+//   - *ConfluxConfig. The saved config.
+//   - err: error. Non-nil if the lock cannot be taken, the config cannot be loaded or saved, or update fails.
+func UpdateConfig(update func(config *ConfluxConfig) error) (*ConfluxConfig, error) {
+	configDir, stateDir, unlock, err := lockConfig()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := update(config); err != nil {
+		return nil, err
+	}
+	if err := saveConfig(configDir, stateDir, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// lockConfig creates the config and state directories and takes the config lock.
+func lockConfig() (configDir string, stateDir string, unlock func(), err error) {
+	configDir, err = GetConfigDir()
+	if err != nil {
+		return "", "", nil, err
+	}
+	stateDir, err = GetStateDir()
+	if err != nil {
+		return "", "", nil, err
+	}
+	if err := ensureConfigDir(configDir); err != nil {
+		return "", "", nil, err
+	}
+	if err := ensureConfigDir(stateDir); err != nil {
+		return "", "", nil, err
+	}
+	unlock, err = lockConfigDir(configDir)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return configDir, stateDir, unlock, nil
+}
+
+// saveConfig writes the token and conflux.json, migrating a file with an older schema after a backup; the caller
+// holds the config lock.
+func saveConfig(configDir string, stateDir string, config *ConfluxConfig) error {
+	backupPath, version, err := backupOldConfig(configDir)
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	// Write the token first so conflux.json never points at a missing one
+	if err := storeToken(stateDir, config); err != nil {
 		return err
 	}
-	err = os.WriteFile(configFilePath, configFile, 0644)
-	if err != nil {
+	if err := writeConfigFile(configDir, config); err != nil {
 		return err
 	}
+	if backupPath != "" {
+		configFilePath := filepath.Join(configDir, configFileName)
+		Logger.Sugar().Infof("migrated %s from schema %d to %d, backup at %s", configFilePath, version, ConfigSchemaVersion, backupPath)
+		if version < 2 {
+			Logger.Sugar().Warnf("moved the conflux token out of %s; it was readable by other local users, consider re-registering to rotate it", configFilePath)
+		}
+	}
 	return nil
 }
 
-// DeleteConfig removes the config file.
+// DeleteConfig removes the config, secrets, and migration backup files, and the token behind TokenRef if conflux
+// stored it there.
 //
 // Inputs: none.
 //
 // Outputs:
-//   - err: error. Non-nil if the file cannot be removed.
+//   - err: error. Non-nil if a file cannot be removed; a failure to delete the referenced token is only logged.
 func DeleteConfig() error {
-	configDir, err := GetConfigDir()
+	configDir, stateDir, unlock, err := lockConfig()
 	if err != nil {
 		return err
 	}
-	configFilePath := filepath.Join(configDir, "conflux.json")
+	defer unlock()
+	if err := deleteTokenRef(configDir); err != nil {
+		Logger.Sugar().Warnf("failed to delete the stored conflux token: %v", err)
+	}
+	if err := deleteSecrets(stateDir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(stateDir, "runtime")); err != nil {
+		return err
+	}
+	// Migration backups may still hold an inline token
+	backups, _ := filepath.Glob(filepath.Join(configDir, configFileName+".v*.bak"))
+	for _, backup := range backups {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	configFilePath := filepath.Join(configDir, configFileName)
 	return os.Remove(configFilePath)
 }
 
 // RegisterConflux registers a conflux with the guardian and returns RegistrationResponse.
 //
 // Inputs:
-//   - config: *ResgitrationRequest. Registration request (token, guardian, tag, JWT/JWKS, etc.).
+//   - ctx: context.Context. Request context.
+//   - config: *ResgitrationRequest. Registration request (token or secret:// reference, guardian, tag, JWT/JWKS, etc.).
 //
 // Outputs:
 //   - *RegistrationResponse. The registration response (ConfluxID, token).
-//   - err: error. Non-nil if the guardian request fails.
-func RegisterConflux(config *ResgitrationRequest) (*RegistrationResponse, error) {
-	// Marshal the request body
-	body, err := json.Marshal(config)
-	if err != nil {
+//   - err: error. ValidationError for an invalid request or response; *guardian.APIError for rejected requests.
+func RegisterConflux(ctx context.Context, config *ResgitrationRequest) (*RegistrationResponse, error) {
+	if err := Validate(config); err != nil {
 		return nil, err
 	}
-	// Create the request
-	url := fmt.Sprintf("%s/conflux/register", config.Guardian)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	registrationToken, err := secret.Resolve(ctx, config.RegistrationToken)
 	if err != nil {
 		return nil, err
 	}
-	// Set the Authorization header
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.RegistrationToken))
-	req.Header.Set("Content-Type", "application/json")
-
-	// Make the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	client := guardian.NewClient(config.Guardian).WithBearerToken(registrationToken)
+	resp, err := client.RegisterConflux(ctx, &guardian.RegisterConfluxRequest{
+		Tag:      config.Tag,
+		JWT:      config.JWT,
+		JWKSURL:  config.JWKS_url,
+		Audience: config.Audience,
+		Issuer:   config.Issuer,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// if the response is not 200, return an error
-	if !(resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK) {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("failed to register conflux: %s: %s", resp.Status, string(body))
+	registrationResponse := &RegistrationResponse{
+		ConfluxID: resp.ConfluxID,
+		Token:     resp.Token,
 	}
-
-	// Read the response body
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the response body
-	var state RegistrationResponse
-	err = json.Unmarshal(body, &state)
-	if err != nil {
-		return nil, err
+	if err := Validate(registrationResponse); err != nil {
+		return nil, fmt.Errorf("guardian returned an incomplete registration: %w", err)
 	}
-
-	return &state, nil
+	return registrationResponse, nil
 }
 
 // UnregisterConflux unregisters the conflux with the guardian using the registration token.
 //
 // Inputs:
-//   - registrationToken: string. Token used to authenticate.
+//   - ctx: context.Context. Request context.
+//   - registrationToken: string. Token used to authenticate, or a secret:// reference to it.
 //   - config: *ConfluxConfig. Current conflux config (Guardian, ConfluxID).
 //
 // Outputs:
-//   - err: error. Non-nil if the guardian request fails.
-func UnregisterConflux(registrationToken string, config *ConfluxConfig) error {
-	// Create the request
-	url := fmt.Sprintf("%s/conflux/unregister?conflux_id=%s", config.Guardian, config.ConfluxID)
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return err
-	}
-	// Set the Authorization header
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", registrationToken))
-	req.Header.Set("Content-Type", "application/json")
-
-	// Make the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+//   - err: error. Non-nil if the guardian request fails; *guardian.APIError for rejected requests.
+func UnregisterConflux(ctx context.Context, registrationToken string, config *ConfluxConfig) error {
+	registrationToken, err := secret.Resolve(ctx, registrationToken)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	// if the response is not 200, return an error
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-		return fmt.Errorf("failed to unregister conflux: %s: %s", resp.Status, string(body))
-	}
-	return nil
+	client := guardian.NewClient(config.Guardian).WithBearerToken(registrationToken)
+	return client.UnregisterConflux(ctx, config.ConfluxID)
 }
 
 // StartConflux registers the conflux, starts the anchor subprocess, creates a gRPC client, and starts the anchor.
@@ -263,14 +351,10 @@ func UnregisterConflux(registrationToken string, config *ConfluxConfig) error {
 //   - anchor: pb.AnchorClient. The gRPC client.
 //   - err: error. Non-nil if registration or anchor start fails.
 func StartConflux(token string, ip string, tag string, idp *IDPConfig, tracer *TracerConfig) (subprocess *exec.Cmd, anchor pb.AnchorClient, err error) {
-
-
-	guardian := "https://guardian.veilnet.app"
-
 	// Parse the command
 	registrationRequest := &ResgitrationRequest{
 		RegistrationToken: token,
-		Guardian:          guardian,
+		Guardian:          guardian.DefaultURL,
 		Tag:               tag,
 	}
 
@@ -282,47 +366,26 @@ func StartConflux(token string, ip string, tag string, idp *IDPConfig, tracer *T
 	}
 
 	// Register the conflux
-	registrationResponse, err := RegisterConflux(registrationRequest)
+	registrationResponse, err := RegisterConflux(context.Background(), registrationRequest)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Initialize the anchor plugin
-	subprocess, err = NewAnchor()
+	// Start the anchor plugin and wait for its gRPC server
+	subprocess, anchor, err = NewReadyAnchor(context.Background(), DefaultReadyOptions())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Wait for the subprocess to start
-	time.Sleep(1 * time.Second)
-
-	// Create a gRPC client connection
-	anchor, err = NewAnchorClient()
+	tracerConfig, err := TracerRequest(context.Background(), tracer)
 	if err != nil {
 		subprocess.Process.Kill()
 		return nil, nil, err
 	}
 
-	var tracerConfig *pb.TracerConfig
-	if tracer == nil {
-		tracerConfig = &pb.TracerConfig{
-			Enabled: false,
-		}
-	} else {
-		tracerConfig = &pb.TracerConfig{
-			Enabled:  tracer.Enabled,
-			Endpoint: tracer.Endpoint,
-			UseTls:   tracer.UseTLS,
-			Insecure: tracer.Insecure,
-			Ca:       tracer.CAFile,
-			Cert:     tracer.CertFile,
-			Key:      tracer.KeyFile,
-		}
-	}
-
 	// Start the anchor
 	_, err = anchor.StartAnchor(context.Background(), &pb.StartAnchorRequest{
-		GuardianUrl: guardian,
+		GuardianUrl: guardian.DefaultURL,
 		AnchorToken: registrationResponse.Token,
 		Ip:          ip,
 		Tracer:      tracerConfig,
@@ -333,3 +396,40 @@ func StartConflux(token string, ip string, tag string, idp *IDPConfig, tracer *T
 	}
 	return subprocess, anchor, nil
 }
+
+// TracerRequest converts the tracer config to its protobuf form; a nil config disables tracing.
+// Certificate and key fields holding secret:// references are written to private files whose paths are passed instead.
+//
+// Inputs:
+//   - ctx: context.Context. Request context for secret backends.
+//   - tracer: *TracerConfig. The tracer config, may be nil.
+//
+// Outputs:
+//   - *pb.TracerConfig. The tracer config for StartAnchor.
+//   - err: error. Non-nil if a referenced secret cannot be resolved or written.
+func TracerRequest(ctx context.Context, tracer *TracerConfig) (*pb.TracerConfig, error) {
+	if tracer == nil {
+		return &pb.TracerConfig{Enabled: false}, nil
+	}
+	ca, err := materializeSecret(ctx, "otlp-ca.pem", tracer.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := materializeSecret(ctx, "otlp-cert.pem", tracer.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := materializeSecret(ctx, "otlp-key.pem", tracer.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TracerConfig{
+		Enabled:  tracer.Enabled,
+		Endpoint: tracer.Endpoint,
+		UseTls:   tracer.UseTLS,
+		Insecure: tracer.Insecure,
+		Ca:       ca,
+		Cert:     cert,
+		Key:      key,
+	}, nil
+}
@@ -0,0 +1,281 @@
+package anchor
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/veil-net/conflux/secret"
+)
+
+// FieldError describes one invalid field by its dotted JSON key, e.g. tracer.endpoint.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error returns "field: message".
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every invalid field of a config or request.
+type ValidationError []FieldError
+
+// Error returns the field errors joined with "; ".
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Without drops the errors of the given fields, e.g. those a later step fills in.
+//
+// Inputs:
+//   - fields: ...string. Dotted JSON keys to ignore.
+//
+// Outputs:
+//   - err: error. The remaining errors, or nil if none remain.
+func (e ValidationError) Without(fields ...string) error {
+	var remaining ValidationError
+	for _, fieldErr := range e {
+		if !slices.Contains(fields, fieldErr.Field) {
+			remaining = append(remaining, fieldErr)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	return remaining
+}
+
+// fieldValidator is implemented by structs with rules that span several fields.
+type fieldValidator interface {
+	validateFields(prefix string) ValidationError
+}
+
+// Validate checks a struct against its validate tags and cross-field rules, descending into non-nil nested structs.
+//
+// Tag rules, comma-separated:
+//   - required: the field is non-empty.
+//   - required_if=Field: the field is non-empty when the bool Field is true.
+//   - url: if set, an absolute http or https URL.
+//   - ip_or_cidr: if set, an IP address or CIDR prefix.
+//   - endpoint: if set, a host:port pair or an http or https URL.
+//   - secret_ref: if set, a well-formed secret:// reference.
+//
+// Inputs:
+//   - v: any. A pointer to a struct such as *ConfluxConfig or *ResgitrationRequest.
+//
+// Outputs:
+//   - err: error. A ValidationError listing every invalid field, or nil.
+func Validate(v any) error {
+	errs := validateStruct(reflect.ValueOf(v), "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateStruct applies the tag rules of each field of a struct and its cross-field rules.
+//
+// Inputs:
+//   - v: reflect.Value. A struct or pointer to one; nil pointers are valid.
+//   - prefix: string. The dotted key of the struct, with a trailing dot, or "" at the top level.
+//
+// Outputs:
+//   - ValidationError. The field errors, in field order.
+func validateStruct(v reflect.Value, prefix string) ValidationError {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var errs ValidationError
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Pointer && value.Type().Elem().Kind() == reflect.Struct {
+			errs = append(errs, validateStruct(value, prefix+name+".")...)
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if message := checkRule(v, value, rule); message != "" {
+				errs = append(errs, FieldError{Field: prefix + name, Message: message})
+				break
+			}
+		}
+	}
+
+	if validator, ok := v.Addr().Interface().(fieldValidator); ok {
+		errs = append(errs, validator.validateFields(prefix)...)
+	}
+	return errs
+}
+
+// checkRule applies one tag rule to a field.
+//
+// Inputs:
+//   - parent: reflect.Value. The struct holding the field, for required_if.
+//   - value: reflect.Value. The field.
+//   - rule: string. The rule, e.g. "required" or "required_if=Enabled".
+//
+// Outputs:
+//   - string. Why the field is invalid, or "" if it is valid.
+func checkRule(parent reflect.Value, value reflect.Value, rule string) string {
+	rule, param, _ := strings.Cut(rule, "=")
+	if rule == "required" || rule == "required_if" {
+		if rule == "required_if" && !parent.FieldByName(param).Bool() {
+			return ""
+		}
+		if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			if rule == "required_if" {
+				return fmt.Sprintf("is required when %s is set", jsonNameOf(parent.Type(), param))
+			}
+			return "is required"
+		}
+		return ""
+	}
+
+	s := value.String()
+	if s == "" {
+		return ""
+	}
+	switch rule {
+	case "url":
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Sprintf("%q is not an http or https URL", s)
+		}
+	case "ip_or_cidr":
+		if _, err := netip.ParseAddr(s); err != nil {
+			if _, err := netip.ParsePrefix(s); err != nil {
+				return fmt.Sprintf("%q is not an IP address or CIDR prefix", s)
+			}
+		}
+	case "endpoint":
+		if u, err := url.Parse(s); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return ""
+		}
+		host, port, err := net.SplitHostPort(s)
+		if err != nil || host == "" || port == "" {
+			return fmt.Sprintf("%q is not a host:port pair or URL", s)
+		}
+	case "secret_ref":
+		if _, err := secret.Parse(s); err != nil {
+			return err.Error()
+		}
+	default:
+		return fmt.Sprintf("unknown validation rule %q", rule)
+	}
+	return ""
+}
+
+// jsonNameOf returns the JSON name of a struct field by its Go name.
+func jsonNameOf(t reflect.Type, fieldName string) string {
+	if field, ok := t.FieldByName(fieldName); ok {
+		return jsonName(field)
+	}
+	return fieldName
+}
+
+// validateFields rejects a config with both rift and portal set.
+func (c *ConfluxConfig) validateFields(prefix string) ValidationError {
+	if c.Rift && c.Portal {
+		return ValidationError{{Field: prefix + "portal", Message: "rift and portal are mutually exclusive"}}
+	}
+	return nil
+}
+
+// validateFields checks the TLS files of an enabled tracer: they must exist, and a client certificate needs its key.
+func (t *TracerConfig) validateFields(prefix string) ValidationError {
+	if !t.Enabled || !t.UseTLS {
+		return nil
+	}
+	var errs ValidationError
+	files := []struct {
+		name string
+		path string
+	}{
+		{"ca_file", t.CAFile},
+		{"cert_file", t.CertFile},
+		{"key_file", t.KeyFile},
+	}
+	for _, file := range files {
+		if message := checkFile(file.path); message != "" {
+			errs = append(errs, FieldError{Field: prefix + file.name, Message: message})
+		}
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		errs = append(errs, FieldError{Field: prefix + "key_file", Message: "cert_file and key_file must be set together"})
+	}
+	return errs
+}
+
+// validateFields requires the JWKS URL, audience, and issuer together when any of them is set.
+func (r *ResgitrationRequest) validateFields(prefix string) ValidationError {
+	if r.JWKS_url == "" && r.Audience == "" && r.Issuer == "" {
+		return nil
+	}
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"jwks_url", r.JWKS_url},
+		{"audience", r.Audience},
+		{"issuer", r.Issuer},
+	}
+	var errs ValidationError
+	for _, field := range fields {
+		if field.value == "" {
+			errs = append(errs, FieldError{Field: prefix + field.name, Message: "is required when an identity provider is configured"})
+		}
+	}
+	return errs
+}
+
+// checkFile checks that a TLS file path names a readable regular file; secret:// references are checked for syntax
+// only, since they are resolved when the anchor starts.
+//
+// Inputs:
+//   - path: string. A file path, secret:// reference, or "".
+//
+// Outputs:
+//   - string. Why the path is invalid, or "" if it is valid or empty.
+func checkFile(path string) string {
+	if path == "" {
+		return ""
+	}
+	if secret.IsRef(path) {
+		if _, err := secret.Parse(path); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("%q does not exist or is not accessible", path)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Sprintf("%q is not a regular file", path)
+	}
+	return ""
+}
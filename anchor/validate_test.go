@@ -0,0 +1,147 @@
+package anchor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// validConfig returns a config that passes Validate.
+func validConfig() *ConfluxConfig {
+	return &ConfluxConfig{
+		ConfluxID: "11111111-1111-1111-1111-111111111111",
+		Token:     "token",
+		Guardian:  "https://guardian.veilnet.app",
+		Taints:    []string{},
+		Tracer:    &TracerConfig{},
+	}
+}
+
+// fieldsOf returns the fields a Validate error names, or nil for a nil error.
+func fieldsOf(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate error %v is not a ValidationError", err)
+	}
+	fields := make([]string, len(validationErr))
+	for i, fieldErr := range validationErr {
+		fields[i] = fieldErr.Field
+	}
+	return fields
+}
+
+func TestValidateConfluxConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	for _, path := range []string{certFile, keyFile} {
+		if err := os.WriteFile(path, []byte("pem"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *ConfluxConfig)
+		want   []string
+	}{
+		{"valid", func(c *ConfluxConfig) {}, nil},
+		{"missing id and token", func(c *ConfluxConfig) { c.ConfluxID = ""; c.Token = "" }, []string{"conflux_id", "conflux_token"}},
+		{"missing guardian", func(c *ConfluxConfig) { c.Guardian = "" }, []string{"guardian"}},
+		{"guardian without scheme", func(c *ConfluxConfig) { c.Guardian = "guardian.veilnet.app" }, []string{"guardian"}},
+		{"guardian with other scheme", func(c *ConfluxConfig) { c.Guardian = "ftp://guardian.veilnet.app" }, []string{"guardian"}},
+		{"ip address", func(c *ConfluxConfig) { c.IP = "10.0.0.5" }, nil},
+		{"ip prefix", func(c *ConfluxConfig) { c.IP = "10.0.0.0/24" }, nil},
+		{"ipv6 address", func(c *ConfluxConfig) { c.IP = "fd00::1" }, nil},
+		{"bad ip", func(c *ConfluxConfig) { c.IP = "10.0.0.256" }, []string{"ip"}},
+		{"rift and portal", func(c *ConfluxConfig) { c.Rift = true; c.Portal = true }, []string{"portal"}},
+		{"neither rift nor portal", func(c *ConfluxConfig) { c.Rift = false; c.Portal = false }, nil},
+		{"token reference", func(c *ConfluxConfig) { c.TokenRef = "secret://keyring/conflux-token" }, nil},
+		{"bad token reference", func(c *ConfluxConfig) { c.TokenRef = "keyring/conflux-token" }, []string{"conflux_token_ref"}},
+		{"nil tracer", func(c *ConfluxConfig) { c.Tracer = nil }, nil},
+		{"tracer without endpoint", func(c *ConfluxConfig) { c.Tracer.Enabled = true }, []string{"tracer.endpoint"}},
+		{"tracer host and port", func(c *ConfluxConfig) { c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317"} }, nil},
+		{"tracer url", func(c *ConfluxConfig) { c.Tracer = &TracerConfig{Enabled: true, Endpoint: "https://otel.example.com"} }, nil},
+		{"tracer bad endpoint", func(c *ConfluxConfig) { c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel"} }, []string{"tracer.endpoint"}},
+		{"disabled tracer is not checked", func(c *ConfluxConfig) { c.Tracer = &TracerConfig{UseTLS: true, CAFile: "/missing"} }, nil},
+		{"tls files exist", func(c *ConfluxConfig) {
+			c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}
+		}, nil},
+		{"tls file missing", func(c *ConfluxConfig) {
+			c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CAFile: filepath.Join(dir, "missing.pem")}
+		}, []string{"tracer.ca_file"}},
+		{"tls file is a directory", func(c *ConfluxConfig) {
+			c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CAFile: dir}
+		}, []string{"tracer.ca_file"}},
+		{"tls cert without key", func(c *ConfluxConfig) {
+			c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CertFile: certFile}
+		}, []string{"tracer.key_file"}},
+		{"tls reference is not opened", func(c *ConfluxConfig) {
+			c.Tracer = &TracerConfig{Enabled: true, Endpoint: "otel:4317", UseTLS: true, CAFile: "secret://file//missing/ca.pem"}
+		}, nil},
+		{"idp missing fields", func(c *ConfluxConfig) { c.IDP = &IDPConfig{JWKS_url: "https://idp.example.com/jwks"} }, []string{"idp.audience", "idp.issuer"}},
+		{"idp bad url", func(c *ConfluxConfig) {
+			c.IDP = &IDPConfig{JWKS_url: "idp.example.com", Audience: "conflux", Issuer: "https://idp.example.com"}
+		}, []string{"idp.jwks_url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(config)
+			got := fieldsOf(t, Validate(config))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRegistrationRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ResgitrationRequest
+		want    []string
+	}{
+		{"valid", ResgitrationRequest{RegistrationToken: "token", Guardian: "https://guardian.veilnet.app"}, nil},
+		{"missing token and guardian", ResgitrationRequest{}, []string{"registration_token", "guardian"}},
+		{"complete idp", ResgitrationRequest{
+			RegistrationToken: "token", Guardian: "https://guardian.veilnet.app",
+			JWKS_url: "https://idp.example.com/jwks", Audience: "conflux", Issuer: "https://idp.example.com",
+		}, nil},
+		{"partial idp", ResgitrationRequest{RegistrationToken: "token", Guardian: "https://guardian.veilnet.app", Audience: "conflux"},
+			[]string{"jwks_url", "issuer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldsOf(t, Validate(&tt.request))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorWithout(t *testing.T) {
+	config := validConfig()
+	config.ConfluxID = ""
+	config.Token = ""
+	err := Validate(config)
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate error = %v, want a ValidationError", err)
+	}
+	if remaining := validationErr.Without("conflux_id", "conflux_token"); remaining != nil {
+		t.Errorf("Without both fields = %v, want nil", remaining)
+	}
+	remaining := validationErr.Without("conflux_id")
+	if got := fieldsOf(t, remaining); !slices.Equal(got, []string{"conflux_token"}) {
+		t.Errorf("Without conflux_id fields = %v, want [conflux_token]", got)
+	}
+}
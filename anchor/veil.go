@@ -0,0 +1,48 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/veil-net/conflux/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ErrVeilMismatch is returned by CheckVeil when the anchor connected to a veil the conflux is not pinned to. Guardian
+// assigns the same veil again on a reconnect, so retrying does not help until the pin or the realm changes.
+var ErrVeilMismatch = errors.New("anchor connected to a veil the conflux is not pinned to")
+
+// CheckVeil verifies the anchor's veil matches the region/veil the conflux is pinned to, stopping the anchor if not.
+// The pin is detect-and-stop, not prevention: neither StartAnchor nor Guardian's registration accepts a region or
+// veil, since Guardian assigns the veil of the realm, so the anchor has already connected when this runs.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: pb.AnchorClient. A started anchor.
+//   - config: *ConfluxConfig. Config with optional Region and Veil pins.
+//
+// Outputs:
+//   - err: error. Non-nil if the veil info cannot be read; ErrVeilMismatch if it does not match the pins.
+func CheckVeil(ctx context.Context, client pb.AnchorClient, config *ConfluxConfig) error {
+	if config.Region == "" && config.Veil == "" {
+		return nil
+	}
+	info, err := client.GetVeilInfo(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+
+	var mismatch error
+	if config.Region != "" && !strings.EqualFold(info.GetRegion(), config.Region) {
+		mismatch = fmt.Errorf("%w: veil %s is in region %q, but the conflux is pinned to region %q", ErrVeilMismatch, info.GetVeilHost(), info.GetRegion(), config.Region)
+	} else if config.Veil != "" && !strings.EqualFold(info.GetVeilHost(), config.Veil) {
+		mismatch = fmt.Errorf("%w: connected to veil %s, but the conflux is pinned to veil %s", ErrVeilMismatch, info.GetVeilHost(), config.Veil)
+	}
+	if mismatch != nil {
+		client.StopAnchor(ctx, &emptypb.Empty{})
+		return mismatch
+	}
+	return nil
+}
@@ -10,15 +10,18 @@ import (
 // Logger re-exports the global logger for CLI use.
 var Logger = logger.Logger
 
-// CLI is the root command with run, install, start, stop, remove, status and up, down, register, unregister, info, taint subcommands.
+// CLI is the root command with run, install, start, stop, remove, status, up, down, register, unregister, info, taint, login, logout, whoami, token, realm, fleet, org, team, veil, networks, plan, apply, secret, config, identity subcommands.
 type CLI struct {
-	Version kong.VersionFlag `short:"v" help:"Print the version and exit"`
-	Run     Run              `cmd:"run" default:"true" help:"Run the conflux service"`
-	Install Install          `cmd:"install" help:"Install the conflux service, this will not update registration data"`
-	Start   Start            `cmd:"start" help:"Start the conflux service"`
-	Stop    Stop             `cmd:"stop" help:"Stop the conflux service"`
-	Remove  Remove           `cmd:"remove" help:"Remove the conflux service, this will not update registration data"`
-	Status  Status           `cmd:"status" help:"Get the status of the conflux service"`
+	Version   kong.VersionFlag `short:"v" help:"Print the version and exit"`
+	ConfigDir string           `help:"Directory for conflux.json and its secrets, default: /etc/conflux and /var/lib/conflux for root, which the installed service runs as, and XDG directories when another user runs conflux in the foreground" env:"VEILNET_CONFIG_DIR" type:"path" json:"config_dir"`
+	Instance  string           `help:"Use a named conflux instance with its own config, state, and service, e.g. to switch a host between realms; instances share the anchor port, so only one can be installed as a service at a time" env:"VEILNET_CONFLUX_INSTANCE" json:"instance"`
+
+	Run     Run     `cmd:"run" default:"true" help:"Run the conflux service"`
+	Install Install `cmd:"install" help:"Install the conflux service, this will not update registration data"`
+	Start   Start   `cmd:"start" help:"Start the conflux service"`
+	Stop    Stop    `cmd:"stop" help:"Stop the conflux service"`
+	Remove  Remove  `cmd:"remove" help:"Remove the conflux service, this will not update registration data"`
+	Status  Status  `cmd:"status" help:"Get the status of the conflux service"`
 
 	Up         Up         `cmd:"up" help:"Start the veilnet service with a conflux token"`
 	Down       Down       `cmd:"down" help:"Stop the veilnet service and remove the conflux token"`
@@ -26,6 +29,22 @@ type CLI struct {
 	Unregister Unregister `cmd:"unregister" help:"Unregister the conflux and remove the service"`
 	Info       Info       `cmd:"info" help:"Get the info of the conflux"`
 	Taint      Taint      `cmd:"taint" help:"Add or remove taints"`
+
+	Login    Login    `cmd:"login" help:"Log in to Guardian and cache the user session for management commands"`
+	Logout   Logout   `cmd:"logout" help:"Remove the cached user session"`
+	Whoami   Whoami   `cmd:"whoami" help:"Show the logged-in user"`
+	Token    Token    `cmd:"token" help:"Create, list, or revoke registration tokens"`
+	Realm    Realm    `cmd:"realm" help:"Create, show, list, or delete realms"`
+	Fleet    Fleet    `cmd:"fleet" help:"Inspect or delete the confluxes in your realms"`
+	Org      Org      `cmd:"org" help:"Manage organisations and their owners"`
+	Team     Team     `cmd:"team" help:"Manage teams, invitations, realm bindings, and conflux membership"`
+	Veil     Veil     `cmd:"veil" help:"List veils and their latency"`
+	Networks Networks `cmd:"networks" help:"Show the subnets confluxes expose or reach, as Guardian reports them; the running anchor cannot be queried locally yet"`
+	Plan     Plan     `cmd:"plan" help:"Show how live realms, teams, and tokens drift from a fleet manifest"`
+	Apply    Apply    `cmd:"apply" help:"Converge realms, teams, and tokens to a fleet manifest"`
+	Secret   Secret   `cmd:"secret" help:"Store, check, or delete secrets behind secret:// references"`
+	Config   Config   `cmd:"config" help:"Show, get, set, validate, or edit the local conflux config"`
+	Identity Identity `cmd:"identity" help:"Export or import the conflux identity to move it to another host"`
 }
 
 // Run runs the conflux service in the foreground.
@@ -41,8 +60,7 @@ type Run struct{}
 func (cmd *Run) Run() error {
 	Logger.Sugar().Infof("Starting VeilNet Conflux...")
 	conflux := service.NewService()
-	conflux.Run()
-	return nil
+	return conflux.Run()
 }
 
 // Install installs the conflux service without updating registration data.
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veil-net/conflux/guardian"
+	"github.com/veil-net/conflux/manifest"
+	"golang.org/x/term"
+)
+
+// Plan shows the changes needed to converge Guardian to a fleet manifest without making them.
+type Plan struct {
+	File             string `short:"f" required:"" type:"existingfile" help:"The fleet manifest (YAML or JSON)" json:"file"`
+	Prune            bool   `help:"Also delete realms, teams, and tokens the manifest does not declare" json:"prune"`
+	DetailedExitcode bool   `help:"Exit with 2 when there is drift, 0 when in sync, and 1 on error" json:"detailed_exitcode"`
+	Output           string `short:"o" help:"Output format: text, json or yaml" enum:"text,json,yaml" default:"text" json:"output"`
+}
+
+// Apply converges Guardian to a fleet manifest.
+type Apply struct {
+	File   string `short:"f" required:"" type:"existingfile" help:"The fleet manifest (YAML or JSON)" json:"file"`
+	Prune  bool   `help:"Also delete realms, teams, and tokens the manifest does not declare" json:"prune"`
+	Yes    bool   `short:"y" help:"Apply without asking for confirmation; required when stdin is not a terminal" json:"yes"`
+	Output string `short:"o" help:"Output format: text, json or yaml" enum:"text,json,yaml" default:"text" json:"output"`
+}
+
+// exitError is a command error that carries a specific process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for the error.
+func (e *exitError) ExitCode() int { return e.code }
+
+// Run computes and prints the plan.
+//
+// Inputs:
+//   - cmd: *Plan. Manifest path, pruning, exit code mode, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil on failure, or an exit code 2 error when --detailed-exitcode is set and there is drift.
+func (cmd *Plan) Run() error {
+	ctx := context.Background()
+	_, plan, err := planManifest(ctx, cmd.File, cmd.Prune)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output != "text" {
+		err = printStructured(cmd.Output, plan)
+	} else {
+		printPlan(plan)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cmd.DetailedExitcode && plan.Drift() > 0 {
+		return &exitError{code: 2, err: fmt.Errorf("%d change(s) pending", plan.Drift())}
+	}
+	return nil
+}
+
+// Run computes the plan, asks for confirmation, and applies it.
+//
+// Inputs:
+//   - cmd: *Apply. Manifest path, pruning, confirmation, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if planning fails, the plan has conflicts, confirmation is refused, or a change fails.
+func (cmd *Apply) Run() error {
+	ctx := context.Background()
+	client, plan, err := planManifest(ctx, cmd.File, cmd.Prune)
+	if err != nil {
+		return err
+	}
+
+	text := cmd.Output == "text"
+	if text {
+		printPlan(plan)
+	}
+	if len(plan.Changes) == 0 {
+		if !text {
+			return printStructured(cmd.Output, &manifest.Result{})
+		}
+		return nil
+	}
+	if conflicts := plan.Count(manifest.ActionConflict); conflicts > 0 {
+		err := fmt.Errorf("plan has %d conflict(s), resolve them before applying", conflicts)
+		Logger.Sugar().Errorf("%v", err)
+		return err
+	}
+
+	if !cmd.Yes {
+		ok, err := confirm("Apply these changes?")
+		if err != nil {
+			Logger.Sugar().Errorf("%v, pass --yes to apply non-interactively", err)
+			return err
+		}
+		if !ok {
+			return errors.New("apply cancelled")
+		}
+	}
+
+	result, err := plan.Apply(ctx, client, func(change manifest.Change) {
+		Logger.Sugar().Infof("%s %s %s", change.Action, change.Kind, change.Name)
+	})
+
+	// Created token secrets are printed even after a failure, they cannot be retrieved again
+	if !text {
+		if printErr := printStructured(cmd.Output, result); printErr != nil && err == nil {
+			err = printErr
+		}
+	} else if len(result.Tokens) > 0 {
+		t := newTable("REALM", "TAG", "TOKEN ID", "TOKEN")
+		for _, token := range result.Tokens {
+			t.Row(token.Realm, token.Tag, token.TokenID, token.Token)
+		}
+		if flushErr := t.Flush(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to apply manifest: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("applied %d change(s)", len(result.Applied))
+	return nil
+}
+
+// planManifest loads a manifest and diffs it against Guardian with the user session.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - path: string. The manifest file.
+//   - prune: bool. Whether undeclared resources are deleted.
+//
+// Outputs:
+//   - *guardian.Client. The user client, for applying the plan.
+//   - *manifest.Plan. The plan.
+//   - err: error. Non-nil if the manifest is invalid, the session is missing, or Guardian cannot be read.
+func planManifest(ctx context.Context, path string, prune bool) (*guardian.Client, *manifest.Plan, error) {
+	m, err := manifest.Load(path)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load manifest: %v", err)
+		return nil, nil, err
+	}
+
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return nil, nil, err
+	}
+
+	// The local conflux token lets the planner read this node's live team membership
+	opts := manifest.Options{Prune: prune, ConfluxTokens: map[string]string{}}
+	if local := localConfig(); local != nil && local.ConfluxID != "" && local.Token != "" {
+		opts.ConfluxTokens[local.ConfluxID] = local.Token
+	}
+
+	plan, err := manifest.NewPlan(ctx, client, m, opts)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to plan manifest: %v", err)
+		return nil, nil, err
+	}
+	return client, plan, nil
+}
+
+// printPlan writes a human-readable plan to stdout.
+func printPlan(plan *manifest.Plan) {
+	symbols := map[manifest.Action]string{
+		manifest.ActionCreate:   "+",
+		manifest.ActionUpdate:   "~",
+		manifest.ActionDelete:   "-",
+		manifest.ActionEnsure:   "=",
+		manifest.ActionConflict: "!",
+	}
+	for _, change := range plan.Changes {
+		line := fmt.Sprintf("%s %s %s", symbols[change.Action], change.Kind, change.Name)
+		if change.Detail != "" {
+			line += ": " + change.Detail
+		}
+		fmt.Println(line)
+	}
+	if plan.Drift() == 0 {
+		fmt.Println("No drift, live state matches the manifest.")
+		return
+	}
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete, %d to ensure, %d conflict(s).\n",
+		plan.Count(manifest.ActionCreate),
+		plan.Count(manifest.ActionUpdate),
+		plan.Count(manifest.ActionDelete),
+		plan.Count(manifest.ActionEnsure),
+		plan.Count(manifest.ActionConflict))
+}
+
+// confirm asks a yes/no question on the terminal.
+//
+// Inputs:
+//   - question: string. The question, without the [y/N] suffix.
+//
+// Outputs:
+//   - bool. True if the answer is y or yes.
+//   - err: error. Non-nil if stdin is not a terminal or cannot be read.
+func confirm(question string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, errors.New("stdin is not a terminal")
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
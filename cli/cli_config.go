@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/veil-net/conflux/anchor"
+	pb "github.com/veil-net/conflux/proto"
+	"github.com/veil-net/conflux/service"
+)
+
+// Config shows, reads, changes, validates, edits, or migrates the local conflux config via show/get/set/validate/edit/migrate subcommands.
+type Config struct {
+	Show     ConfigShow     `cmd:"show" help:"Print the local config with secrets redacted"`
+	Get      ConfigGet      `cmd:"get" help:"Print one config value"`
+	Set      ConfigSet      `cmd:"set" help:"Change one config value"`
+	Validate ConfigValidate `cmd:"validate" help:"Check that the local config loads and is complete"`
+	Edit     ConfigEdit     `cmd:"edit" help:"Edit the local config in $EDITOR, validating it before saving"`
+	Migrate  ConfigMigrate  `cmd:"migrate" help:"Rewrite the local config at the current schema version, keeping a backup of the old file"`
+}
+
+// ConfigShow prints the local config.
+type ConfigShow struct {
+	Output string `short:"o" help:"Output format: json or yaml" enum:"json,yaml" default:"json" json:"output"`
+}
+
+// Run loads the config and prints it with the token and IdP JWT redacted.
+//
+// Inputs:
+//   - cmd: *ConfigShow. Output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the config cannot be loaded or printed.
+func (cmd *ConfigShow) Run() error {
+	config, err := anchor.LoadConfig()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load config: %v", err)
+		return err
+	}
+	return printStructured(cmd.Output, redactConfig(config))
+}
+
+// ConfigGet prints one config value.
+type ConfigGet struct {
+	Key    string `arg:"" help:"The config key, e.g. guardian, taints, or tracer.endpoint" json:"key"`
+	Reveal bool   `help:"Print secret values such as conflux_token instead of redacting them" json:"reveal"`
+}
+
+// Run loads the config and prints the value of the key; lists are printed one item per line.
+//
+// Inputs:
+//   - cmd: *ConfigGet. The key and whether to reveal secrets.
+//
+// Outputs:
+//   - err: error. Non-nil if the config cannot be loaded or the key is unknown.
+func (cmd *ConfigGet) Run() error {
+	config, err := anchor.LoadConfig()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load config: %v", err)
+		return err
+	}
+	value, err := anchor.GetConfigValue(config, cmd.Key)
+	if err != nil {
+		Logger.Sugar().Errorf("%v; known keys: %s", err, strings.Join(anchor.ConfigKeys(), ", "))
+		return err
+	}
+
+	if anchor.IsSecretConfigKey(cmd.Key) && !cmd.Reveal && value != "" {
+		value = anchor.RedactedValue
+	}
+	if items, ok := value.([]string); ok {
+		for _, item := range items {
+			fmt.Println(item)
+		}
+		return nil
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// ConfigSet changes one config value.
+type ConfigSet struct {
+	Key   string `arg:"" help:"The config key, e.g. guardian, taints, or tracer.endpoint" json:"key"`
+	Value string `arg:"" help:"The new value; lists are comma-separated and booleans are true or false" json:"value"`
+	Apply bool   `help:"Also apply taints to the running anchor right away, e.g. one started with up --debug, which does not watch the config" json:"apply"`
+}
+
+// Run sets the value, validates and saves the config, and optionally applies the change to the running anchor.
+//
+// Inputs:
+//   - cmd: *ConfigSet. The key, value, and apply flag.
+//
+// Outputs:
+//   - err: error. Non-nil if the value is invalid or the config cannot be saved or applied.
+func (cmd *ConfigSet) Run() error {
+	var previous []string
+	config, err := anchor.UpdateConfig(func(config *anchor.ConfluxConfig) error {
+		previous = slices.Clone(config.Taints)
+		if err := anchor.SetConfigValue(config, cmd.Key, cmd.Value); err != nil {
+			return fmt.Errorf("%w; known keys: %s", err, strings.Join(anchor.ConfigKeys(), ", "))
+		}
+		// Rift and portal are mutually exclusive, so turning one on turns the other off
+		switch {
+		case cmd.Key == "rift" && config.Rift:
+			config.Portal = false
+		case cmd.Key == "portal" && config.Portal:
+			config.Rift = false
+		}
+		if err := anchor.Validate(config); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to set %s: %v", cmd.Key, err)
+		return err
+	}
+	Logger.Sugar().Infof("set %s and updated config, %s", cmd.Key, service.ReloadEffect([]string{cmd.Key}))
+
+	if !cmd.Apply || !slices.Contains(anchor.RuntimeConfigKeys, cmd.Key) {
+		return nil
+	}
+	return applyTaints(previous, config.Taints)
+}
+
+// ConfigValidate checks the local config.
+type ConfigValidate struct{}
+
+// Run loads the config, including its token, and checks it.
+//
+// Inputs:
+//   - cmd: *ConfigValidate. No options.
+//
+// Outputs:
+//   - err: error. Non-nil if the config cannot be loaded or is incomplete.
+func (cmd *ConfigValidate) Run() error {
+	config, err := anchor.LoadConfig()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load config: %v", err)
+		return err
+	}
+	if err := anchor.Validate(config); err != nil {
+		Logger.Sugar().Errorf("invalid config: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("config is valid")
+	return nil
+}
+
+// ConfigEdit opens the local config in an editor.
+type ConfigEdit struct{}
+
+// Run writes the config without its token to a private temporary file, opens $VISUAL or $EDITOR on it, and saves
+// the result once it parses and validates. Invalid edits are reopened on request instead of being saved.
+//
+// Inputs:
+//   - cmd: *ConfigEdit. No options.
+//
+// Outputs:
+//   - err: error. Non-nil if the editor fails, the edit is abandoned, or the config cannot be saved.
+func (cmd *ConfigEdit) Run() error {
+	config, err := anchor.LoadConfig()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load config: %v", err)
+		return err
+	}
+
+	editable := *config
+	editable.Token = ""
+	if editable.IDP != nil {
+		idp := *editable.IDP
+		idp.JWT = ""
+		editable.IDP = &idp
+	}
+	original, err := json.MarshalIndent(&editable, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", "conflux-*.json")
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create temporary file: %v", err)
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(append(original, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to write temporary file: %v", err)
+		return err
+	}
+
+	for {
+		if err := runEditor(file.Name()); err != nil {
+			Logger.Sugar().Errorf("failed to run editor: %v", err)
+			return err
+		}
+		edited, err := os.ReadFile(file.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(original)) {
+			Logger.Sugar().Infof("config unchanged")
+			return nil
+		}
+
+		updated, err := parseEditedConfig(edited, config)
+		if err == nil {
+			err = anchor.Validate(updated)
+		}
+		if err == nil {
+			_, err := anchor.UpdateConfig(func(current *anchor.ConfluxConfig) error {
+				// Another command changed the config while it was open in the editor
+				if changed := anchor.DiffConfig(config, current); len(changed) > 0 {
+					return fmt.Errorf("conflux.json changed while it was being edited (%s), run config edit again", strings.Join(changed, ", "))
+				}
+				*current = *updated
+				return nil
+			})
+			if err != nil {
+				Logger.Sugar().Errorf("failed to save config: %v", err)
+				return err
+			}
+			if effect := service.ReloadEffect(anchor.DiffConfig(config, updated)); effect != "" {
+				Logger.Sugar().Infof("updated config, %s", effect)
+			} else {
+				Logger.Sugar().Infof("updated config")
+			}
+			return nil
+		}
+
+		Logger.Sugar().Errorf("invalid config: %v", err)
+		again, confirmErr := confirm("Edit again?")
+		if confirmErr != nil || !again {
+			Logger.Sugar().Errorf("config not saved")
+			return err
+		}
+	}
+}
+
+// ConfigMigrate rewrites the local config at the current schema version.
+type ConfigMigrate struct{}
+
+// Run migrates conflux.json under the config lock; other commands only upgrade an older file in memory until they
+// save it.
+//
+// Inputs:
+//   - cmd: *ConfigMigrate. No options.
+//
+// Outputs:
+//   - err: error. Non-nil if the config cannot be loaded or saved.
+func (cmd *ConfigMigrate) Run() error {
+	migrated, err := anchor.MigrateConfig()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to migrate config: %v", err)
+		return err
+	}
+	if !migrated {
+		Logger.Sugar().Infof("config is already at schema %d", anchor.ConfigSchemaVersion)
+		return nil
+	}
+	Logger.Sugar().Infof("migrated config to schema %d", anchor.ConfigSchemaVersion)
+	return nil
+}
+
+// parseEditedConfig decodes an edited config, keeping the secrets that were left out of the editable copy.
+//
+// Inputs:
+//   - edited: []byte. The edited JSON.
+//   - current: *anchor.ConfluxConfig. The config before editing.
+//
+// Outputs:
+//   - *anchor.ConfluxConfig. The edited config.
+//   - err: error. Non-nil if the JSON is invalid, has unknown keys, or changes the schema version or owned token reference.
+func parseEditedConfig(edited []byte, current *anchor.ConfluxConfig) (*anchor.ConfluxConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(edited))
+	decoder.DisallowUnknownFields()
+	updated := &anchor.ConfluxConfig{}
+	if err := decoder.Decode(updated); err != nil {
+		return nil, err
+	}
+	if updated.SchemaVersion != current.SchemaVersion {
+		return nil, errors.New("schema_version is read-only")
+	}
+	if updated.OwnedTokenRef != current.OwnedTokenRef {
+		return nil, errors.New("conflux_token_ref_owned is read-only")
+	}
+
+	if updated.Token == "" {
+		updated.Token = current.Token
+	}
+	if updated.IDP != nil && updated.IDP.JWT == "" && current.IDP != nil {
+		updated.IDP.JWT = current.IDP.JWT
+	}
+	if updated.Tracer == nil {
+		updated.Tracer = &anchor.TracerConfig{}
+	}
+	if updated.Taints == nil {
+		updated.Taints = []string{}
+	}
+	return updated, nil
+}
+
+// runEditor opens path in $VISUAL, $EDITOR, or the platform default editor, attached to the terminal.
+//
+// Inputs:
+//   - path: string. The file to edit.
+//
+// Outputs:
+//   - err: error. Non-nil if the editor cannot be started or exits with an error.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// EDITOR may carry arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	editorCmd := exec.Command(args[0], append(args[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	return editorCmd.Run()
+}
+
+// applyTaints adds and removes taints on the running anchor so it matches the config.
+//
+// Inputs:
+//   - previous: []string. The taints before the change.
+//   - current: []string. The taints after the change.
+//
+// Outputs:
+//   - err: error. Non-nil if the anchor cannot be reached or rejects a change.
+func applyTaints(previous []string, current []string) error {
+	client, err := anchor.NewAnchorClient()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create anchor gRPC client: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+	for _, taint := range current {
+		if slices.Contains(previous, taint) {
+			continue
+		}
+		if _, err := client.AddTaint(ctx, &pb.AddTaintRequest{Taint: taint}); err != nil {
+			Logger.Sugar().Errorf("failed to add taint: %v", err)
+			return err
+		}
+		Logger.Sugar().Infof("added taint %q to the running conflux", taint)
+	}
+	for _, taint := range previous {
+		if slices.Contains(current, taint) {
+			continue
+		}
+		if _, err := client.RemoveTaint(ctx, &pb.RemoveTaintRequest{Taint: taint}); err != nil {
+			Logger.Sugar().Errorf("failed to remove taint: %v", err)
+			return err
+		}
+		Logger.Sugar().Infof("removed taint %q from the running conflux", taint)
+	}
+	return nil
+}
+
+// redactConfig returns a copy of the config with its token and IdP JWT replaced by anchor.RedactedValue.
+//
+// Inputs:
+//   - config: *anchor.ConfluxConfig. The config.
+//
+// Outputs:
+//   - *anchor.ConfluxConfig. The redacted copy.
+func redactConfig(config *anchor.ConfluxConfig) *anchor.ConfluxConfig {
+	redacted := *config
+	if redacted.Token != "" {
+		redacted.Token = anchor.RedactedValue
+	}
+	if redacted.IDP != nil && redacted.IDP.JWT != "" {
+		idp := *redacted.IDP
+		idp.JWT = anchor.RedactedValue
+		redacted.IDP = &idp
+	}
+	return &redacted
+}
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Fleet inspects and deletes the confluxes known to Guardian via list/get/delete subcommands.
+type Fleet struct {
+	List   FleetList   `cmd:"list" default:"1" help:"List confluxes, marking the local node"`
+	Get    FleetGet    `cmd:"get" help:"Show a conflux (default: the local node)"`
+	Delete FleetDelete `cmd:"delete" help:"Delete confluxes"`
+}
+
+// fleetEntry is a conflux as printed by the fleet commands; the private key is never printed.
+type fleetEntry struct {
+	guardian.Conflux
+	KeyPEM string   `json:"key_pem,omitempty"`
+	Local  bool     `json:"local"`
+	Taints []string `json:"taints,omitempty"`
+}
+
+// FleetList lists confluxes with optional tag, realm, and taint filters.
+type FleetList struct {
+	Tag    string `help:"Only show confluxes with this tag" json:"tag"`
+	Realm  string `short:"r" help:"Only show confluxes in this realm (ID or name)" json:"realm"`
+	Taint  string `help:"Only show confluxes with this taint; Guardian does not report taints, so only the local node can match" json:"taint"`
+	Output string `short:"o" help:"Output format: table, json or yaml" enum:"table,json,yaml" default:"table" json:"output"`
+}
+
+// Run lists confluxes from Guardian and marks the entry matching the local conflux ID.
+//
+// Inputs:
+//   - cmd: *FleetList. Filters and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *FleetList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	confluxes, err := client.ListConflux(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list confluxes: %v", err)
+		return err
+	}
+
+	local := localConfig()
+	entries := make([]fleetEntry, 0, len(confluxes))
+	for _, conflux := range confluxes {
+		entry := newFleetEntry(conflux, local)
+		if cmd.Tag != "" && entry.Tag != cmd.Tag {
+			continue
+		}
+		if cmd.Realm != "" && entry.RealmID != cmd.Realm && entry.Realm != cmd.Realm {
+			continue
+		}
+		if cmd.Taint != "" && !slices.Contains(entry.Taints, cmd.Taint) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if cmd.Output != "table" {
+		return printStructured(cmd.Output, entries)
+	}
+	t := newTable(" ", "ID", "TAG", "REALM", "CIDR", "PORTAL", "PUBLIC", "REGION", "LAST SEEN")
+	for _, entry := range entries {
+		marker := " "
+		if entry.Local {
+			marker = "*"
+		}
+		lastSeen := ""
+		if entry.LastSeen != nil {
+			lastSeen = formatTime(*entry.LastSeen)
+		}
+		t.Row(marker, entry.ID, entry.Tag, entry.Realm, entry.CIDR, strconv.FormatBool(entry.Portal), strconv.FormatBool(entry.Public), entry.Region, lastSeen)
+	}
+	return t.Flush()
+}
+
+// FleetGet shows a single conflux.
+type FleetGet struct {
+	ConfluxID string `arg:"" optional:"" name:"conflux-id" help:"The conflux ID, default: the local conflux"`
+	Output    string `short:"o" help:"Output format: table, json or yaml" enum:"table,json,yaml" default:"table" json:"output"`
+}
+
+// Run fetches and prints the conflux.
+//
+// Inputs:
+//   - cmd: *FleetGet. Conflux ID and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if no ID is known, the session is missing, or the request fails.
+func (cmd *FleetGet) Run() error {
+	confluxID, err := confluxIDOrLocal(cmd.ConfluxID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get conflux: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	conflux, err := client.GetConflux(ctx, confluxID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get conflux: %v", err)
+		return err
+	}
+
+	entry := newFleetEntry(*conflux, localConfig())
+	if cmd.Output != "table" {
+		return printStructured(cmd.Output, entry)
+	}
+	fmt.Println("Conflux Info")
+	fmt.Println("------------")
+	fmt.Printf("  %-10s %s\n", "ID:", entry.ID)
+	fmt.Printf("  %-10s %s\n", "Tag:", entry.Tag)
+	fmt.Printf("  %-10s %s\n", "Realm:", entry.Realm)
+	fmt.Printf("  %-10s %s\n", "Realm ID:", entry.RealmID)
+	fmt.Printf("  %-10s %s\n", "CIDR:", entry.CIDR)
+	fmt.Printf("  %-10s %v\n", "Portal:", entry.Portal)
+	fmt.Printf("  %-10s %v\n", "Public:", entry.Public)
+	fmt.Printf("  %-10s %s:%d\n", "Veil:", entry.VeilHost, entry.VeilPort)
+	fmt.Printf("  %-10s %s\n", "Region:", entry.Region)
+	if entry.LastSeen != nil {
+		fmt.Printf("  %-10s %s\n", "Last Seen:", entry.LastSeen.Local().Format(time.RFC3339))
+	}
+	fmt.Printf("  %-10s %v\n", "Local:", entry.Local)
+	if entry.Local {
+		fmt.Printf("  %-10s %v\n", "Taints:", entry.Taints)
+	}
+	return nil
+}
+
+// FleetDelete deletes confluxes from Guardian.
+type FleetDelete struct {
+	ConfluxIDs []string `arg:"" name:"conflux-id" help:"The IDs of the confluxes to delete"`
+	Force      bool     `short:"f" help:"Allow deleting the local conflux" json:"force"`
+}
+
+// Run deletes each conflux, continuing past failures and returning the last error.
+//
+// Inputs:
+//   - cmd: *FleetDelete. Conflux IDs and force flag.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or any deletion fails.
+func (cmd *FleetDelete) Run() error {
+	local := localConfig()
+	if local != nil && slices.Contains(cmd.ConfluxIDs, local.ConfluxID) && !cmd.Force {
+		err := fmt.Errorf("conflux %s is the local node, use --force or `conflux unregister`", local.ConfluxID)
+		Logger.Sugar().Errorf("failed to delete conflux: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	var lastErr error
+	for _, confluxID := range cmd.ConfluxIDs {
+		if _, err := client.DeleteConflux(ctx, confluxID); err != nil {
+			Logger.Sugar().Errorf("failed to delete conflux %s: %v", confluxID, err)
+			lastErr = err
+			continue
+		}
+		Logger.Sugar().Infof("deleted conflux %s", confluxID)
+	}
+	return lastErr
+}
+
+// localConfig returns the local conflux config, or nil if this host is not registered.
+func localConfig() *anchor.ConfluxConfig {
+	config, err := anchor.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	return config
+}
+
+// newFleetEntry wraps a conflux for output, marking it local and attaching taints when it matches local.
+func newFleetEntry(conflux guardian.Conflux, local *anchor.ConfluxConfig) fleetEntry {
+	entry := fleetEntry{Conflux: conflux}
+	if local != nil && local.ConfluxID != "" && local.ConfluxID == conflux.ID {
+		entry.Local = true
+		entry.Taints = local.Taints
+	}
+	return entry
+}
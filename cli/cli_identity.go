@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+	"github.com/veil-net/conflux/service"
+)
+
+// IdentityPassphraseEnv holds the passphrase of identity bundles.
+const IdentityPassphraseEnv = "VEILNET_IDENTITY_PASSPHRASE"
+
+// identityOnlineWindow is how recently Guardian must have seen a conflux after its export for import to refuse it.
+const identityOnlineWindow = 5 * time.Minute
+
+// Identity moves a conflux to another host via export/import subcommands.
+type Identity struct {
+	Export IdentityExport `cmd:"export" help:"Write the conflux identity to an encrypted bundle and retire it on this host"`
+	Import IdentityImport `cmd:"import" help:"Take over a conflux identity from an encrypted bundle"`
+}
+
+// IdentityExport writes the local conflux identity to an encrypted bundle.
+type IdentityExport struct {
+	Output         string `arg:"" help:"The bundle file to write" type:"path" json:"output"`
+	PassphraseFile string `help:"Read the bundle passphrase from this file instead of the terminal" env:"VEILNET_IDENTITY_PASSPHRASE_FILE" type:"existingfile" json:"passphrase_file"`
+	Keep           bool   `help:"Keep the conflux able to run on this host, e.g. for a backup; importing the bundle then needs --force" json:"keep"`
+	Force          bool   `help:"Overwrite an existing bundle file" json:"force"`
+}
+
+// Run exports the config, token, and tracer certificates, then records the export so this host refuses to start the
+// conflux again and removes the service. Removing it only stops the anchor: the service does not log the conflux out
+// of Guardian, which would delete a rift conflux before the new host takes it over.
+//
+// Inputs:
+//   - cmd: *IdentityExport. The bundle path, passphrase source, and options.
+//
+// Outputs:
+//   - err: error. Non-nil if the config cannot be read or the bundle cannot be written.
+func (cmd *IdentityExport) Run() error {
+	passphrase, err := identityPassphrase(cmd.PassphraseFile, true)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to read passphrase: %v", err)
+		return err
+	}
+
+	bundle, err := anchor.ExportIdentity(context.Background(), !cmd.Keep)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to export identity: %v", err)
+		return err
+	}
+	data, err := anchor.SealIdentity(bundle, passphrase)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to encrypt identity bundle: %v", err)
+		return err
+	}
+	if err := writeBundle(cmd.Output, data, cmd.Force); err != nil {
+		Logger.Sugar().Errorf("failed to write identity bundle: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("exported conflux %s to %s", bundle.Config.ConfluxID, cmd.Output)
+
+	if cmd.Keep {
+		Logger.Sugar().Warnf("conflux %s can still run on this host, stop it before importing the bundle elsewhere", bundle.Config.ConfluxID)
+		return nil
+	}
+
+	// Record the export first, so a service manager restarting the conflux is refused
+	if err := anchor.MarkIdentityExported(bundle); err != nil {
+		Logger.Sugar().Errorf("failed to retire identity on this host: %v", err)
+		return err
+	}
+	// Stop the anchor without a Guardian logout, so the conflux stays registered for the new host
+	conflux := service.NewService()
+	if err := conflux.Remove(); err != nil {
+		Logger.Sugar().Warnf("failed to remove the conflux service, stop it before importing the bundle elsewhere: %v", err)
+	}
+	Logger.Sugar().Infof("conflux %s is retired on this host, run `conflux identity import` on the new host", bundle.Config.ConfluxID)
+	return nil
+}
+
+// IdentityImport takes over a conflux identity from a bundle.
+type IdentityImport struct {
+	Bundle         string `arg:"" help:"The bundle file written by identity export" type:"existingfile" json:"bundle"`
+	PassphraseFile string `help:"Read the bundle passphrase from this file instead of the terminal" env:"VEILNET_IDENTITY_PASSPHRASE_FILE" type:"existingfile" json:"passphrase_file"`
+	TokenStore     string `help:"Keep the conflux token in this secret:// reference instead of secrets.json, which is private to the owner but not encrypted" env:"VEILNET_CONFLUX_TOKEN_STORE" json:"token_store"`
+	Force          bool   `help:"Import even if this host has another conflux, the bundle was exported with --keep, or Guardian saw the conflux after the export" json:"force"`
+}
+
+// Run decrypts the bundle, checks that the conflux is not still running elsewhere, and saves its config here.
+//
+// Inputs:
+//   - cmd: *IdentityImport. The bundle path, passphrase source, and options.
+//
+// Outputs:
+//   - err: error. Non-nil if the bundle cannot be opened, a safeguard refuses it, or the config cannot be saved.
+func (cmd *IdentityImport) Run() error {
+	ctx := context.Background()
+	data, err := os.ReadFile(cmd.Bundle)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to read identity bundle: %v", err)
+		return err
+	}
+	passphrase, err := identityPassphrase(cmd.PassphraseFile, false)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to read passphrase: %v", err)
+		return err
+	}
+	bundle, err := anchor.OpenIdentity(data, passphrase)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to open identity bundle: %v", err)
+		return err
+	}
+	confluxID := bundle.Config.ConfluxID
+
+	if !cmd.Force {
+		if err := checkIdentityFree(ctx, bundle); err != nil {
+			Logger.Sugar().Errorf("refusing to import conflux %s: %v; pass --force to import anyway", confluxID, err)
+			return err
+		}
+	}
+
+	if err := anchor.ImportIdentity(bundle, cmd.TokenStore); err != nil {
+		Logger.Sugar().Errorf("failed to import identity: %v", err)
+		return err
+	}
+	noteTokenStore(cmd.TokenStore)
+	Logger.Sugar().Infof("imported conflux %s exported from %s at %s, run `conflux install` to start it",
+		confluxID, bundle.Host, formatTime(bundle.ExportedAt))
+	return nil
+}
+
+// checkIdentityFree checks that importing a bundle will not run its conflux on two hosts or replace another conflux.
+// Guardian is asked when the conflux was last seen if a user session is available; otherwise a warning is logged.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - bundle: *anchor.IdentityBundle. The bundle to import.
+//
+// Outputs:
+//   - err: error. Non-nil with the reason if the import is unsafe.
+func checkIdentityFree(ctx context.Context, bundle *anchor.IdentityBundle) error {
+	confluxID := bundle.Config.ConfluxID
+	if local := localConfig(); local != nil && local.ConfluxID != confluxID {
+		return fmt.Errorf("this host runs conflux %s, unregister it first", local.ConfluxID)
+	}
+	if !bundle.Retired {
+		return fmt.Errorf("the bundle was exported with --keep, so %s may still run it", bundle.Host)
+	}
+
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Warnf("cannot ask Guardian whether conflux %s still runs on %s: %v", confluxID, bundle.Host, err)
+		return nil
+	}
+	conflux, err := client.GetConflux(ctx, confluxID)
+	if err != nil {
+		Logger.Sugar().Warnf("cannot ask Guardian whether conflux %s still runs on %s: %v", confluxID, bundle.Host, err)
+		return nil
+	}
+	if conflux.LastSeen != nil && conflux.LastSeen.After(bundle.ExportedAt) && time.Since(*conflux.LastSeen) < identityOnlineWindow {
+		return fmt.Errorf("it was last seen by Guardian at %s, after the export, so %s may still run it", formatTime(*conflux.LastSeen), bundle.Host)
+	}
+	return nil
+}
+
+// identityPassphrase returns the bundle passphrase from VEILNET_IDENTITY_PASSPHRASE, a file, or the terminal.
+//
+// Inputs:
+//   - file: string. A file holding the passphrase, or "" to prompt.
+//   - repeat: bool. Ask twice when prompting, for a new bundle.
+//
+// Outputs:
+//   - string. The passphrase.
+//   - err: error. Non-nil if it cannot be read, is empty, or the two entries differ.
+func identityPassphrase(file string, repeat bool) (string, error) {
+	var passphrase string
+	switch {
+	case os.Getenv(IdentityPassphraseEnv) != "":
+		passphrase = os.Getenv(IdentityPassphraseEnv)
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	default:
+		hint := "use --passphrase-file or " + IdentityPassphraseEnv
+		var err error
+		passphrase, err = promptSecret("Bundle passphrase: ", hint)
+		if err != nil {
+			return "", err
+		}
+		if repeat {
+			again, err := promptSecret("Repeat passphrase: ", hint)
+			if err != nil {
+				return "", err
+			}
+			if again != passphrase {
+				return "", errors.New("passphrases do not match")
+			}
+		}
+	}
+	if passphrase == "" {
+		return "", errors.New("passphrase is empty")
+	}
+	return passphrase, nil
+}
+
+// writeBundle writes a bundle readable only by the current user, refusing to replace a file unless force is set.
+//
+// Inputs:
+//   - path: string. The bundle file.
+//   - data: []byte. The encrypted bundle.
+//   - force: bool. Overwrite an existing file.
+//
+// Outputs:
+//   - err: error. Non-nil if the file exists without force or cannot be written.
+func writeBundle(path string, data []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, pass --force to overwrite it", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
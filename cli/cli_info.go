@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 
 	"github.com/veil-net/conflux/anchor"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -14,6 +16,7 @@ type Info struct {
 	Realm   InfoRealm   `cmd:"realm" help:"Show realm info (realm, realm ID, subnet)"`
 	Veil    InfoVeil    `cmd:"veil" help:"Show veil connection info (host, port, region)"`
 	Tracer  InfoTracer  `cmd:"tracer" help:"Show tracer config (enabled, endpoint, use TLS, insecure, CA, cert, key)"`
+	Anchor  InfoAnchor  `cmd:"anchor" help:"Show anchor subprocess status (PID, started, restarts, last exit, crash loop)"`
 }
 
 // InfoConflux shows conflux info (ID, tag, UID, CIDR, portal, public).
@@ -138,4 +141,35 @@ func (cmd *InfoTracer) Run() error {
 	fmt.Printf("  %-10s %s\n", "Cert:", info.GetCert())
 	fmt.Printf("  %-10s %s\n", "Key:", info.GetKey())
 	return nil
-}
\ No newline at end of file
+}
+
+// InfoAnchor shows anchor subprocess status (PID, started, restarts, last exit, crash loop).
+type InfoAnchor struct{}
+
+// Run prints the anchor subprocess status recorded by the service supervisor.
+//
+// Inputs:
+//   - cmd: *InfoAnchor. The subcommand.
+//
+// Outputs:
+//   - err: error. Non-nil if the service has not run or the status cannot be read.
+func (cmd *InfoAnchor) Run() error {
+	status, err := anchor.LoadAnchorStatus()
+	if errors.Is(err, fs.ErrNotExist) {
+		Logger.Sugar().Errorf("no anchor status recorded, the conflux service has not run")
+		return err
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load anchor status: %v", err)
+		return err
+	}
+	fmt.Println("Anchor Info")
+	fmt.Println("-----------")
+	fmt.Printf("  %-11s %d\n", "PID:", status.PID)
+	fmt.Printf("  %-11s %s\n", "Started:", formatTime(status.StartedAt))
+	fmt.Printf("  %-11s %d\n", "Restarts:", status.Restarts)
+	fmt.Printf("  %-11s %s\n", "Last exit:", status.LastExit)
+	fmt.Printf("  %-11s %s\n", "Exited at:", formatTime(status.LastExitAt))
+	fmt.Printf("  %-11s %v\n", "Crash loop:", status.CrashLoop)
+	return nil
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+	"github.com/veil-net/conflux/guardian"
+	"golang.org/x/term"
+)
+
+// sessionLeeway is how long before expiry a cached user session is renewed.
+const sessionLeeway = time.Minute
+
+// Login authenticates a user with Guardian and caches the session for management commands.
+type Login struct {
+	Email         string `short:"e" help:"The account email, prompted for if omitted" env:"VEILNET_EMAIL" json:"email"`
+	PasswordStdin bool   `help:"Read the password from stdin instead of prompting" json:"password_stdin"`
+	Guardian      string `help:"The Guardian URL (Authentication Server), default: https://guardian.veilnet.app" default:"https://guardian.veilnet.app" env:"VEILNET_GUARDIAN" json:"guardian"`
+}
+
+// Run prompts for credentials, logs in, and saves the session under the config directory.
+//
+// Inputs:
+//   - cmd: *Login. Email, password source, and guardian URL.
+//
+// Outputs:
+//   - err: error. Non-nil if reading credentials, login, or saving the session fails.
+func (cmd *Login) Run() error {
+	reader := bufio.NewReader(os.Stdin)
+
+	email := cmd.Email
+	if email == "" {
+		if cmd.PasswordStdin {
+			err := errors.New("--email is required with --password-stdin")
+			Logger.Sugar().Errorf("failed to read credentials: %v", err)
+			return err
+		}
+		fmt.Fprint(os.Stderr, "Email: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			Logger.Sugar().Errorf("failed to read email: %v", err)
+			return err
+		}
+		email = strings.TrimSpace(line)
+	}
+
+	var password string
+	if cmd.PasswordStdin {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			Logger.Sugar().Errorf("failed to read password from stdin: %v", err)
+			return err
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		var err error
+		password, err = promptPassword()
+		if err != nil {
+			Logger.Sugar().Errorf("failed to read password: %v", err)
+			return err
+		}
+	}
+
+	session, err := authenticate(context.Background(), cmd.Guardian, email, password)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to log in: %v", err)
+		return err
+	}
+
+	Logger.Sugar().Infof("logged in to %s as %s", session.Guardian, session.Email)
+	return nil
+}
+
+// Logout removes the cached user session.
+type Logout struct{}
+
+// Run deletes the cached session; it succeeds if no session exists.
+//
+// Inputs:
+//   - cmd: *Logout. The logout command.
+//
+// Outputs:
+//   - err: error. Non-nil if the session file cannot be removed.
+func (cmd *Logout) Run() error {
+	err := anchor.DeleteSession()
+	if errors.Is(err, fs.ErrNotExist) {
+		Logger.Sugar().Infof("not logged in")
+		return nil
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to delete session: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("logged out")
+	return nil
+}
+
+// Whoami shows the profile of the logged-in user.
+type Whoami struct{}
+
+// Run prints the user profile from Guardian using the cached session.
+//
+// Inputs:
+//   - cmd: *Whoami. The whoami command.
+//
+// Outputs:
+//   - err: error. Non-nil if there is no valid session or the request fails.
+func (cmd *Whoami) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+	profile, err := client.GetProfile(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get profile: %v", err)
+		return err
+	}
+	fmt.Println("User Info")
+	fmt.Println("---------")
+	fmt.Printf("  %-10s %s\n", "ID:", profile.ID)
+	fmt.Printf("  %-10s %s\n", "Email:", profile.Email)
+	fmt.Printf("  %-10s %s\n", "Name:", profile.DisplayName)
+	fmt.Printf("  %-10s %s\n", "Guardian:", client.BaseURL())
+	return nil
+}
+
+// authenticate logs in to Guardian, verifies the token against the profile endpoint, and caches the session.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - guardianURL, email, password: string. Guardian URL and user credentials.
+//
+// Outputs:
+//   - *anchor.Session. The saved session.
+//   - err: error. Non-nil if login, profile lookup, or saving fails.
+func authenticate(ctx context.Context, guardianURL string, email string, password string) (*anchor.Session, error) {
+	client := guardian.NewClient(guardianURL)
+	token, err := client.Login(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := client.WithBearerToken(token.AccessToken).GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := anchor.TokenExpiry(token.AccessToken)
+	if token.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	session := &anchor.Session{
+		Guardian:     client.BaseURL(),
+		Email:        profile.Email,
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    expiresAt,
+	}
+	if err := anchor.SaveSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// userClient returns a Guardian client authenticated with the cached user session.
+// An expired session is renewed by prompting for the password when stdin is a terminal.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - *guardian.Client. The authenticated client.
+//   - err: error. Non-nil if there is no usable session.
+func userClient(ctx context.Context) (*guardian.Client, error) {
+	session, err := anchor.LoadSession()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.New("not logged in, run `conflux login` first")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Expired(sessionLeeway) {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, errors.New("session expired, run `conflux login` again")
+		}
+		Logger.Sugar().Infof("session for %s expired, please re-enter your password", session.Email)
+		password, err := promptPassword()
+		if err != nil {
+			return nil, err
+		}
+		session, err = authenticate(ctx, session.Guardian, session.Email, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return guardian.NewClient(session.Guardian).WithBearerToken(session.AccessToken), nil
+}
+
+// promptPassword reads a password from the terminal without echo.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - string. The password.
+//   - err: error. Non-nil if stdin is not a terminal or reading fails.
+func promptPassword() (string, error) {
+	return promptSecret("Password: ", "use --password-stdin")
+}
+
+// promptSecret reads a secret from the terminal without echo.
+//
+// Inputs:
+//   - prompt: string. Text written to stderr before reading.
+//   - hint: string. Appended to the error when stdin is not a terminal.
+//
+// Outputs:
+//   - string. The secret.
+//   - err: error. Non-nil if stdin is not a terminal or reading fails.
+func promptSecret(prompt string, hint string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("stdin is not a terminal, %s", hint)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Networks lists the subnets confluxes expose or, with --remote, the subnets they reach and through which peers.
+type Networks struct {
+	Remote  bool   `help:"Show the remote networks each conflux reaches instead of the networks it exposes" json:"remote"`
+	Conflux string `short:"c" help:"Only show this conflux ID, default: every conflux in your realms" json:"conflux"`
+	Output  string `short:"o" help:"Output format: table, json or yaml" enum:"table,json,yaml" default:"table" json:"output"`
+}
+
+// networkEntry is a single subnet row; Peer fields are only set for remote networks.
+type networkEntry struct {
+	ConfluxID     string `json:"conflux_id"`
+	Tag           string `json:"tag,omitempty"`
+	Local         bool   `json:"local"`
+	Subnet        string `json:"subnet"`
+	PeerSignature string `json:"peer_signature,omitempty"`
+	PeerID        string `json:"peer_id,omitempty"`
+	PeerTag       string `json:"peer_tag,omitempty"`
+}
+
+// Run queries Guardian for the local or remote networks of each selected conflux and prints them. The running
+// anchor is not asked: it answers Guardian's network queries over its control channel, and veilnet.proto has no RPC
+// to ask it locally, so a conflux whose networks Guardian does not know cannot be shown yet.
+//
+// Inputs:
+//   - cmd: *Networks. Direction, conflux filter, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or listing confluxes fails; per-conflux failures are logged.
+func (cmd *Networks) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	// The conflux list resolves peer signatures to IDs and tags
+	confluxes, err := client.ListConflux(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list confluxes: %v", err)
+		return err
+	}
+	bySignature := make(map[string]guardian.Conflux, len(confluxes))
+	for _, conflux := range confluxes {
+		if conflux.Signature != "" {
+			bySignature[conflux.Signature] = conflux
+		}
+	}
+
+	targets := confluxes
+	if cmd.Conflux != "" {
+		targets = nil
+		for _, conflux := range confluxes {
+			if conflux.ID == cmd.Conflux {
+				targets = append(targets, conflux)
+			}
+		}
+		if targets == nil {
+			targets = []guardian.Conflux{{ID: cmd.Conflux}}
+		}
+	}
+
+	local := localConfig()
+	entries := []networkEntry{}
+	for _, conflux := range targets {
+		isLocal := local != nil && local.ConfluxID == conflux.ID
+		if !cmd.Remote {
+			networks, err := client.GetConfluxLocalNetworks(ctx, conflux.ID)
+			if err != nil {
+				Logger.Sugar().Warnf("failed to get local networks of conflux %s: %v", conflux.ID, err)
+				continue
+			}
+			for _, network := range networks.GetLocalNetworks() {
+				entries = append(entries, networkEntry{
+					ConfluxID: conflux.ID,
+					Tag:       conflux.Tag,
+					Local:     isLocal,
+					Subnet:    network.GetSubnet(),
+				})
+			}
+			continue
+		}
+
+		networks, err := client.GetConfluxRemoteNetworks(ctx, conflux.ID)
+		if err != nil {
+			Logger.Sugar().Warnf("failed to get remote networks of conflux %s: %v", conflux.ID, err)
+			continue
+		}
+		for _, network := range networks.GetRemoteNetworks() {
+			peer := bySignature[network.GetPeerSignature()]
+			entries = append(entries, networkEntry{
+				ConfluxID:     conflux.ID,
+				Tag:           conflux.Tag,
+				Local:         isLocal,
+				Subnet:        network.GetSubnet(),
+				PeerSignature: network.GetPeerSignature(),
+				PeerID:        peer.ID,
+				PeerTag:       peer.Tag,
+			})
+		}
+	}
+
+	if cmd.Output != "table" {
+		return printStructured(cmd.Output, entries)
+	}
+	var t *table
+	if cmd.Remote {
+		t = newTable(" ", "CONFLUX", "TAG", "SUBNET", "VIA PEER", "PEER TAG")
+	} else {
+		t = newTable(" ", "CONFLUX", "TAG", "SUBNET")
+	}
+	for _, entry := range entries {
+		marker := " "
+		if entry.Local {
+			marker = "*"
+		}
+		if !cmd.Remote {
+			t.Row(marker, entry.ConfluxID, entry.Tag, entry.Subnet)
+			continue
+		}
+		peer := entry.PeerID
+		if peer == "" {
+			peer = shortSignature(entry.PeerSignature)
+		}
+		t.Row(marker, entry.ConfluxID, entry.Tag, entry.Subnet, peer, entry.PeerTag)
+	}
+	return t.Flush()
+}
+
+// shortSignature abbreviates a peer signature for table output.
+func shortSignature(signature string) string {
+	if len(signature) <= 16 {
+		return signature
+	}
+	return signature[:16] + "…"
+}
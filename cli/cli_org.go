@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Org manages organisations via create/list/get/update/delete/owner subcommands.
+type Org struct {
+	Create OrgCreate `cmd:"create" help:"Create an organisation"`
+	List   OrgList   `cmd:"list" help:"List organisations"`
+	Get    OrgGet    `cmd:"get" help:"Show an organisation"`
+	Update OrgUpdate `cmd:"update" help:"Update an organisation"`
+	Delete OrgDelete `cmd:"delete" help:"Delete an organisation"`
+	Owner  OrgOwner  `cmd:"owner" help:"Add or remove organisation owners"`
+}
+
+// OrgCreate creates an organisation.
+type OrgCreate struct {
+	Name    string `arg:"" help:"The organisation name"`
+	Website string `help:"The organisation website" json:"website"`
+	Email   string `help:"The organisation contact email" json:"email"`
+	Output  string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run creates the organisation and prints it.
+//
+// Inputs:
+//   - cmd: *OrgCreate. Name, website, email, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgCreate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	org, err := client.CreateOrganisation(ctx, &guardian.CreateOrganisationRequest{
+		Name:    cmd.Name,
+		Website: cmd.Website,
+		Email:   cmd.Email,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create organisation: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(org)
+	}
+	printOrganisation(org)
+	return nil
+}
+
+// OrgList lists the user's organisations.
+type OrgList struct {
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists organisations.
+//
+// Inputs:
+//   - cmd: *OrgList. Output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	orgs, err := client.ListOrganisations(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list organisations: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(orgs)
+	}
+	t := newTable("ID", "NAME", "WEBSITE", "EMAIL")
+	for _, org := range orgs {
+		t.Row(org.ID, org.Name, org.Website, org.Email)
+	}
+	return t.Flush()
+}
+
+// OrgGet shows an organisation by ID.
+type OrgGet struct {
+	OrganisationID string `arg:"" name:"org-id" help:"The organisation ID"`
+	Output         string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run fetches and prints the organisation.
+//
+// Inputs:
+//   - cmd: *OrgGet. Organisation ID and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgGet) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	org, err := client.GetOrganisation(ctx, cmd.OrganisationID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get organisation: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(org)
+	}
+	printOrganisation(org)
+	return nil
+}
+
+// OrgUpdate changes an organisation's name, website, or email.
+type OrgUpdate struct {
+	OrganisationID string `arg:"" name:"org-id" help:"The organisation ID"`
+	Name           string `help:"The new organisation name" json:"name"`
+	Website        string `help:"The new organisation website" json:"website"`
+	Email          string `help:"The new organisation contact email" json:"email"`
+}
+
+// Run updates the organisation; unset flags are left unchanged.
+//
+// Inputs:
+//   - cmd: *OrgUpdate. Organisation ID and new values.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgUpdate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	err = client.UpdateOrganisation(ctx, cmd.OrganisationID, &guardian.UpdateOrganisationRequest{
+		Name:    cmd.Name,
+		Website: cmd.Website,
+		Email:   cmd.Email,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to update organisation: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("updated organisation %s", cmd.OrganisationID)
+	return nil
+}
+
+// OrgDelete deletes an organisation.
+type OrgDelete struct {
+	OrganisationID string `arg:"" name:"org-id" help:"The organisation ID"`
+}
+
+// Run deletes the organisation.
+//
+// Inputs:
+//   - cmd: *OrgDelete. The organisation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgDelete) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.DeleteOrganisation(ctx, cmd.OrganisationID); err != nil {
+		Logger.Sugar().Errorf("failed to delete organisation: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("deleted organisation %s", cmd.OrganisationID)
+	return nil
+}
+
+// OrgOwner adds or removes organisation owners via add/remove subcommands.
+type OrgOwner struct {
+	Add    OrgOwnerAdd    `cmd:"add" help:"Make a user an owner of the organisation"`
+	Remove OrgOwnerRemove `cmd:"remove" help:"Give up ownership of the organisation"`
+}
+
+// OrgOwnerAdd makes a user an owner of an organisation.
+type OrgOwnerAdd struct {
+	OrganisationID string `arg:"" name:"org-id" help:"The organisation ID"`
+	Email          string `arg:"" help:"The email of the new owner"`
+}
+
+// Run adds the owner.
+//
+// Inputs:
+//   - cmd: *OrgOwnerAdd. Organisation ID and user email.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgOwnerAdd) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	err = client.AddOrganisationOwner(ctx, &guardian.AddOrganisationOwnerRequest{
+		OrganisationID: cmd.OrganisationID,
+		UserEmail:      cmd.Email,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to add organisation owner: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("added %s as owner of organisation %s", cmd.Email, cmd.OrganisationID)
+	return nil
+}
+
+// OrgOwnerRemove removes the logged-in user as an owner of an organisation.
+type OrgOwnerRemove struct {
+	OrganisationID string `arg:"" name:"org-id" help:"The organisation ID"`
+}
+
+// Run removes the ownership.
+//
+// Inputs:
+//   - cmd: *OrgOwnerRemove. The organisation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *OrgOwnerRemove) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.RemoveOrganisationOwner(ctx, cmd.OrganisationID); err != nil {
+		Logger.Sugar().Errorf("failed to remove organisation owner: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("removed ownership of organisation %s", cmd.OrganisationID)
+	return nil
+}
+
+// printOrganisation prints an organisation in the info layout.
+func printOrganisation(org *guardian.Organisation) {
+	fmt.Println("Organisation Info")
+	fmt.Println("-----------------")
+	fmt.Printf("  %-10s %s\n", "ID:", org.ID)
+	fmt.Printf("  %-10s %s\n", "Name:", org.Name)
+	fmt.Printf("  %-10s %s\n", "Website:", org.Website)
+	fmt.Printf("  %-10s %s\n", "Email:", org.Email)
+}
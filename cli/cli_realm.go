@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Realm manages realms in Guardian via create/get/list/delete/subscription subcommands.
+type Realm struct {
+	Create       RealmCreate       `cmd:"create" help:"Create a realm"`
+	Get          RealmGet          `cmd:"get" help:"Show a realm"`
+	List         RealmList         `cmd:"list" help:"List realms"`
+	Delete       RealmDelete       `cmd:"delete" help:"Delete realms"`
+	Subscription RealmSubscription `cmd:"subscription" help:"Attach or detach a realm subscription"`
+}
+
+// RealmCreate creates a realm with a name, subnet, veil, and visibility.
+type RealmCreate struct {
+	Name         string `arg:"" help:"The realm name"`
+	Subnet       string `required:"" help:"The realm subnet in CIDR notation (e.g. 10.128.0.0/16)" json:"subnet"`
+	Veil         string `required:"" help:"The ID of the veil that serves the realm (see: conflux veil list)" json:"veil"`
+	Public       bool   `help:"Make the realm public, default: false" default:"false" json:"public"`
+	Subscription string `help:"The subscription ID to attach to the realm" json:"subscription"`
+	Output       string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run creates the realm and prints it.
+//
+// Inputs:
+//   - cmd: *RealmCreate. Name, subnet, veil, visibility, subscription, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *RealmCreate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	realm, err := client.CreateRealm(ctx, &guardian.CreateRealmRequest{
+		Name:           cmd.Name,
+		Subnet:         cmd.Subnet,
+		Public:         cmd.Public,
+		VeilID:         cmd.Veil,
+		SubscriptionID: cmd.Subscription,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create realm: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(realm)
+	}
+	printRealm(realm)
+	return nil
+}
+
+// RealmGet shows a realm by ID.
+type RealmGet struct {
+	RealmID string `arg:"" name:"realm-id" help:"The realm ID"`
+	Output  string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run fetches and prints the realm.
+//
+// Inputs:
+//   - cmd: *RealmGet. Realm ID and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *RealmGet) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	realm, err := client.GetRealm(ctx, cmd.RealmID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get realm: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(realm)
+	}
+	printRealm(realm)
+	return nil
+}
+
+// RealmList lists the realms visible to the user.
+type RealmList struct {
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists realms.
+//
+// Inputs:
+//   - cmd: *RealmList. Output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *RealmList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	realms, err := client.ListRealms(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list realms: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(realms)
+	}
+	t := newTable("ID", "NAME", "SUBNET", "PUBLIC", "REGION", "PORTALS", "STATUS")
+	for _, realm := range realms {
+		t.Row(realm.ID, realm.Name, realm.Subnet, strconv.FormatBool(realm.Public), realm.Region, strconv.Itoa(realm.Portals), realm.Status)
+	}
+	return t.Flush()
+}
+
+// RealmDelete deletes one or more realms by ID.
+type RealmDelete struct {
+	RealmIDs []string `arg:"" name:"realm-id" help:"The IDs of the realms to delete"`
+}
+
+// Run deletes each realm, continuing past failures and returning the last error.
+//
+// Inputs:
+//   - cmd: *RealmDelete. The realm IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or any deletion fails.
+func (cmd *RealmDelete) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	var lastErr error
+	for _, realmID := range cmd.RealmIDs {
+		realm, err := client.DeleteRealm(ctx, realmID)
+		if err != nil {
+			Logger.Sugar().Errorf("failed to delete realm %s: %v", realmID, err)
+			lastErr = err
+			continue
+		}
+		Logger.Sugar().Infof("deleted realm %s (%s)", realm.Name, realmID)
+	}
+	return lastErr
+}
+
+// RealmSubscription attaches or detaches a realm subscription via set/remove subcommands.
+type RealmSubscription struct {
+	Set    RealmSubscriptionSet    `cmd:"set" help:"Attach a subscription to a realm"`
+	Remove RealmSubscriptionRemove `cmd:"remove" help:"Detach the subscription from a realm"`
+}
+
+// RealmSubscriptionSet attaches a subscription to a realm.
+type RealmSubscriptionSet struct {
+	RealmID        string `arg:"" name:"realm-id" help:"The realm ID"`
+	SubscriptionID string `arg:"" name:"subscription-id" help:"The subscription ID"`
+}
+
+// Run attaches the subscription.
+//
+// Inputs:
+//   - cmd: *RealmSubscriptionSet. Realm and subscription IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *RealmSubscriptionSet) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	err = client.UpdateRealmSubscription(ctx, &guardian.UpdateRealmSubscriptionRequest{
+		RealmID:        cmd.RealmID,
+		SubscriptionID: cmd.SubscriptionID,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to update realm subscription: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("attached subscription %s to realm %s", cmd.SubscriptionID, cmd.RealmID)
+	return nil
+}
+
+// RealmSubscriptionRemove detaches the subscription from a realm.
+type RealmSubscriptionRemove struct {
+	RealmID string `arg:"" name:"realm-id" help:"The realm ID"`
+}
+
+// Run detaches the subscription.
+//
+// Inputs:
+//   - cmd: *RealmSubscriptionRemove. The realm ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *RealmSubscriptionRemove) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.DeleteRealmSubscription(ctx, cmd.RealmID); err != nil {
+		Logger.Sugar().Errorf("failed to remove realm subscription: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("removed subscription from realm %s", cmd.RealmID)
+	return nil
+}
+
+// printRealm prints a realm in the same layout as `conflux info realm`.
+func printRealm(realm *guardian.Realm) {
+	fmt.Println("Realm Info")
+	fmt.Println("----------")
+	fmt.Printf("  %-10s %s\n", "Realm:", realm.Name)
+	fmt.Printf("  %-10s %s\n", "Realm ID:", realm.ID)
+	fmt.Printf("  %-10s %s\n", "Subnet:", realm.Subnet)
+	fmt.Printf("  %-10s %v\n", "Public:", realm.Public)
+	fmt.Printf("  %-10s %s\n", "Veil ID:", realm.VeilID)
+	fmt.Printf("  %-10s %s\n", "Region:", realm.Region)
+	fmt.Printf("  %-10s %d\n", "Portals:", realm.Portals)
+	fmt.Printf("  %-10s %s\n", "Status:", realm.Status)
+}
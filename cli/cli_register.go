@@ -2,37 +2,38 @@ package cli
 
 import (
 	"context"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"errors"
 
+	"github.com/alecthomas/kong"
 	"github.com/veil-net/conflux/anchor"
-	pb "github.com/veil-net/conflux/proto"
 	"github.com/veil-net/conflux/service"
 )
 
-// Register registers a new conflux with a registration token and options (rift, portal, guardian, tag, IP, JWT/JWKS, taints, tracer, debug).
+// Register registers a new conflux with a registration token and options (rift, portal, guardian, tag, IP, JWT/JWKS, taints, region/veil pins, tracer, debug).
 type Register struct {
-	RegistrationToken string   `short:"t" help:"The registration token" env:"VEILNET_REGISTRATION_TOKEN" json:"registration_token"`
-	Rift              bool     `short:"r" help:"Enable rift mode, default: false" default:"false" env:"VEILNET_CONFLUX_RIFT" json:"rift"`
-	Portal            bool     `short:"p" help:"Enable portal mode, default: false" default:"false" env:"VEILNET_CONFLUX_PORTAL" json:"portal"`
-	Guardian          string   `help:"The Guardian URL (Authentication Server), default: https://guardian.veilnet.app" default:"https://guardian.veilnet.app" env:"VEILNET_GUARDIAN" json:"guardian"`
-	Tag               string   `help:"The tag for the conflux" env:"VEILNET_CONFLUX_TAG" json:"tag"`
-	IP                string   `help:"The IP of the conflux" env:"VEILNET_CONFLUX_IP" json:"ip"`
-	JWT               string   `help:"The JWT for the conflux" env:"VEILNET_CONFLUX_JWT" json:"jwt"`
-	JWKS_url          string   `help:"The JWKS URL for the conflux" env:"VEILNET_CONFLUX_JWKS_URL" json:"jwks_url"`
-	Audience          string   `help:"The audience for the conflux" env:"VEILNET_CONFLUX_AUDIENCE" json:"audience"`
-	Issuer            string   `help:"The issuer for the conflux" env:"VEILNET_CONFLUX_ISSUER" json:"issuer"`
-	Taints            []string `help:"Taints for the conflux, conflux can only communicate with other conflux with taints that are either a super set or a subset" env:"VEILNET_CONFLUX_TAINTS" json:"taints"`
-	Debug             bool     `short:"d" help:"Enable debug mode, this will not install the service but run conflux directly" env:"VEILNET_CONFLUX_DEBUG" json:"debug"`
-	Tracer            bool     `help:"Enable tracer, default: false" default:"false" env:"VEILNET_TRACER" json:"tracer"`
-	OTLPEndpoint      string   `help:"The OTLP endpoint for the metrics" env:"VEILNET_OTLP_ENDPOINT" json:"otlp_endpoint"`
-	OTLPUseTLS        bool     `help:"Enable TLS for the metrics" default:"false" env:"VEILNET_OTLP_USE_TLS" json:"otlp_use_tls"`
-	OTLPInsecure      bool     `help:"Enable insecure mode for the metrics" default:"false" env:"VEILNET_OTLP_INSECURE" json:"otlp_insecure"`
-	OTLPCACert        string   `help:"The OTLP CA certificate for the metrics" env:"VEILNET_OTLP_CA_CERT" json:"otlp_ca_cert"`
-	OTLPClientCert    string   `help:"The OTLP client certificate for the metrics" env:"VEILNET_OTLP_CLIENT_CERT" json:"otlp_client_cert"`
-	OTLPClientKey     string   `help:"The OTLP client key for the metrics" env:"VEILNET_OTLP_CLIENT_KEY" json:"otlp_client_key"`
+	Config            kong.ConfigFlag `help:"Read flags from a JSON, YAML, or TOML file keyed like conflux_id or taints; flags and env vars override it" type:"existingfile"`
+	RegistrationToken string          `short:"t" help:"The registration token, or a secret:// reference to it" env:"VEILNET_REGISTRATION_TOKEN" json:"registration_token"`
+	TokenStore        string          `help:"Keep the conflux token in this secret:// reference instead of secrets.json, which is private to the owner but not encrypted" env:"VEILNET_CONFLUX_TOKEN_STORE" json:"token_store"`
+	Rift              bool            `short:"r" help:"Enable rift mode, default: false" default:"false" env:"VEILNET_CONFLUX_RIFT" json:"rift"`
+	Portal            bool            `short:"p" help:"Enable portal mode, default: false" default:"false" env:"VEILNET_CONFLUX_PORTAL" json:"portal"`
+	Guardian          string          `help:"The Guardian URL (Authentication Server), default: https://guardian.veilnet.app" default:"https://guardian.veilnet.app" env:"VEILNET_GUARDIAN" json:"guardian"`
+	Tag               string          `help:"The tag for the conflux" env:"VEILNET_CONFLUX_TAG" json:"tag"`
+	IP                string          `help:"The IP of the conflux" env:"VEILNET_CONFLUX_IP" json:"ip"`
+	JWT               string          `help:"The JWT for the conflux" env:"VEILNET_CONFLUX_JWT" json:"jwt"`
+	JWKS_url          string          `help:"The JWKS URL for the conflux" env:"VEILNET_CONFLUX_JWKS_URL" json:"jwks_url"`
+	Audience          string          `help:"The audience for the conflux" env:"VEILNET_CONFLUX_AUDIENCE" json:"audience"`
+	Issuer            string          `help:"The issuer for the conflux" env:"VEILNET_CONFLUX_ISSUER" json:"issuer"`
+	Taints            []string        `help:"Taints for the conflux, conflux can only communicate with other conflux with taints that are either a super set or a subset" env:"VEILNET_CONFLUX_TAINTS" json:"taints"`
+	Region            string          `help:"Pin the conflux to veils in this region; this detects and stops, it does not prevent: Guardian assigns the veil of the realm, so the anchor connects first and is stopped if that veil is elsewhere, and the service stays down instead of reconnecting. Serve the realm from an in-region veil (realm create --veil) to stay in region" env:"VEILNET_CONFLUX_REGION" json:"region"`
+	Veil              string          `help:"Pin the conflux to this veil host (see: conflux veil list); like --region, the anchor is stopped after it connected to another veil, not kept from connecting" env:"VEILNET_CONFLUX_VEIL" json:"veil"`
+	Debug             bool            `short:"d" help:"Enable debug mode, this will not install the service but run conflux directly" env:"VEILNET_CONFLUX_DEBUG" json:"debug"`
+	Tracer            bool            `help:"Enable tracer, default: false" default:"false" env:"VEILNET_TRACER" json:"tracer"`
+	OTLPEndpoint      string          `help:"The OTLP endpoint for the metrics" env:"VEILNET_OTLP_ENDPOINT" json:"otlp_endpoint"`
+	OTLPUseTLS        bool            `help:"Enable TLS for the metrics" default:"false" env:"VEILNET_OTLP_USE_TLS" json:"otlp_use_tls"`
+	OTLPInsecure      bool            `help:"Enable insecure mode for the metrics" default:"false" env:"VEILNET_OTLP_INSECURE" json:"otlp_insecure"`
+	OTLPCACert        string          `help:"The OTLP CA certificate for the metrics, a path or secret:// reference" env:"VEILNET_OTLP_CA_CERT" json:"otlp_ca_cert"`
+	OTLPClientCert    string          `help:"The OTLP client certificate for the metrics, a path or secret:// reference" env:"VEILNET_OTLP_CLIENT_CERT" json:"otlp_client_cert"`
+	OTLPClientKey     string          `help:"The OTLP client key for the metrics, a path or secret:// reference" env:"VEILNET_OTLP_CLIENT_KEY" json:"otlp_client_key"`
 }
 
 // ConfluxToken holds conflux ID and token (e.g. from registration response).
@@ -61,14 +62,7 @@ func (cmd *Register) Run() error {
 		Issuer:            cmd.Issuer,
 	}
 
-	// Register the conflux
-	registrationResponse, err := anchor.RegisterConflux(registrationRequest)
-	if err != nil {
-		Logger.Sugar().Errorf("failed to register conflux: %v", err)
-		return err
-	}
-
-	// Save the configuration
+	// Build the configuration
 	tracerConfig := &anchor.TracerConfig{
 		Enabled:  cmd.Tracer,
 		UseTLS:   cmd.OTLPUseTLS,
@@ -79,15 +73,47 @@ func (cmd *Register) Run() error {
 		KeyFile:  cmd.OTLPClientKey,
 	}
 	config := &anchor.ConfluxConfig{
-		ConfluxID: registrationResponse.ConfluxID,
-		Token:     registrationResponse.Token,
-		Guardian:  cmd.Guardian,
-		Rift:      cmd.Rift,
-		Portal:    cmd.Portal,
-		IP:        cmd.IP,
-		Taints:    cmd.Taints,
-		Tracer:    tracerConfig,
+		TokenRef:      cmd.TokenStore,
+		OwnedTokenRef: cmd.TokenStore,
+		Guardian:      cmd.Guardian,
+		Rift:          cmd.Rift,
+		Portal:        cmd.Portal,
+		IP:            cmd.IP,
+		Taints:        cmd.Taints,
+		Tracer:        tracerConfig,
+		Region:        cmd.Region,
+		Veil:          cmd.Veil,
 	}
+	if cmd.JWKS_url != "" || cmd.Audience != "" || cmd.Issuer != "" {
+		config.IDP = &anchor.IDPConfig{
+			JWT:      cmd.JWT,
+			JWKS_url: cmd.JWKS_url,
+			Audience: cmd.Audience,
+			Issuer:   cmd.Issuer,
+		}
+	}
+
+	// Validate the flags before the registration token is spent; registration fills in the ID and token
+	if err := anchor.Validate(config); err != nil {
+		var validationErr anchor.ValidationError
+		if errors.As(err, &validationErr) {
+			err = validationErr.Without("conflux_id", "conflux_token")
+		}
+		if err != nil {
+			Logger.Sugar().Errorf("invalid configuration: %v", err)
+			return err
+		}
+	}
+
+	// Register the conflux
+	registrationResponse, err := anchor.RegisterConflux(context.Background(), registrationRequest)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to register conflux: %v", err)
+		return err
+	}
+
+	config.ConfluxID = registrationResponse.ConfluxID
+	config.Token = registrationResponse.Token
 
 	if !cmd.Debug {
 		// Save the configuration
@@ -96,6 +122,7 @@ func (cmd *Register) Run() error {
 			Logger.Sugar().Errorf("failed to save configuration: %v", err)
 			return err
 		}
+		noteTokenStore(config.TokenRef)
 
 		// Install the service
 		conflux := service.NewService()
@@ -113,64 +140,20 @@ func (cmd *Register) Run() error {
 		return nil
 	}
 
-	// Initialize the anchor plugin
-	subprocess, err := anchor.NewAnchor()
-	if err != nil {
-		Logger.Sugar().Errorf("failed to initialize anchor subprocess: %v", err)
-		return err
-	}
-
-	// Wait for the subprocess to start
-	time.Sleep(1 * time.Second)
-
-	// Create a gRPC client connection
-	anchor, err := anchor.NewAnchorClient()
-	if err != nil {
-		subprocess.Process.Kill()
-		Logger.Sugar().Errorf("failed to create anchor gRPC client: %v", err)
-		return err
-	}
-
-	// Start the anchor
-	_, err = anchor.StartAnchor(context.Background(), &pb.StartAnchorRequest{
-		GuardianUrl: config.Guardian,
-		AnchorToken: config.Token,
-		Ip:          config.IP,
-		Portal:      config.Portal,
-		Rift:        config.Rift,
-		Tracer: &pb.TracerConfig{
-			Enabled:  config.Tracer.Enabled,
-			Endpoint: config.Tracer.Endpoint,
-			UseTls:   config.Tracer.UseTLS,
-			Insecure: config.Tracer.Insecure,
-			Ca:       config.Tracer.CAFile,
-			Cert:     config.Tracer.CertFile,
-			Key:      config.Tracer.KeyFile,
-		},
-	})
-	if err != nil {
-		subprocess.Process.Kill()
-		Logger.Sugar().Errorf("failed to start anchor: %v", err)
-		return err
-	}
+	// Run the anchor in the foreground the way the service does, without saving the config
+	return service.RunDebug(config)
+}
 
-	// Add taints
-	for _, taint := range config.Taints {
-		_, err = anchor.AddTaint(context.Background(), &pb.AddTaintRequest{
-			Taint: taint,
-		})
-		if err != nil {
-			Logger.Sugar().Warnf("failed to add taint: %v", err)
-			continue
-		}
+// noteTokenStore points out that the conflux token was stored in plaintext when no secret:// reference was given.
+//
+// Inputs:
+//   - tokenRef: string. The reference the token was stored in, or "" for secrets.json.
+//
+// Outputs: none.
+func noteTokenStore(tokenRef string) {
+	if tokenRef != "" {
+		return
 	}
-
-	// Wait for interrupt signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	// Wait for interrupt signal
-	<-interrupt
-
-	return nil
+	Logger.Sugar().Infof("stored the conflux token unencrypted in secrets.json, readable only by this user; " +
+		"pass --token-store with an encrypted-file, keyring, secret-service, or vault reference to keep it off disk in plaintext")
 }
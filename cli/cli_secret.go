@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/veil-net/conflux/secret"
+	"golang.org/x/term"
+)
+
+// Secret writes, checks, and deletes secrets behind secret:// references via put/check/delete subcommands.
+type Secret struct {
+	Put    SecretPut    `cmd:"put" help:"Store a secret behind a secret:// reference"`
+	Check  SecretCheck  `cmd:"check" help:"Verify that a secret:// reference resolves, without printing it"`
+	Delete SecretDelete `cmd:"delete" help:"Delete the secret behind a secret:// reference"`
+}
+
+// SecretPut stores a secret read from stdin or a file.
+type SecretPut struct {
+	Ref      string `arg:"" help:"The secret:// reference, e.g. secret://encrypted-file//etc/conflux/otlp-key.enc" json:"ref"`
+	FromFile string `help:"Read the secret from this file instead of stdin" type:"existingfile" json:"from_file"`
+}
+
+// Run reads the secret and stores it in the referenced backend.
+//
+// Inputs:
+//   - cmd: *SecretPut. Reference and optional source file.
+//
+// Outputs:
+//   - err: error. Non-nil if the secret cannot be read or stored.
+func (cmd *SecretPut) Run() error {
+	var value []byte
+	var err error
+	if cmd.FromFile != "" {
+		value, err = os.ReadFile(cmd.FromFile)
+	} else {
+		value, err = readSecretInput()
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to read secret: %v", err)
+		return err
+	}
+
+	if err := secret.Store(context.Background(), cmd.Ref, string(value)); err != nil {
+		Logger.Sugar().Errorf("failed to store secret: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("stored %d bytes at %s", len(value), cmd.Ref)
+	return nil
+}
+
+// SecretCheck resolves a reference without printing the secret.
+type SecretCheck struct {
+	Ref string `arg:"" help:"The secret:// reference" json:"ref"`
+}
+
+// Run resolves the reference and reports its size.
+//
+// Inputs:
+//   - cmd: *SecretCheck. The reference.
+//
+// Outputs:
+//   - err: error. Non-nil if the reference does not resolve.
+func (cmd *SecretCheck) Run() error {
+	if !secret.IsRef(cmd.Ref) {
+		return fmt.Errorf("%q is not a secret:// reference", cmd.Ref)
+	}
+	value, err := secret.Resolve(context.Background(), cmd.Ref)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to resolve secret: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("%s resolves to %d bytes", cmd.Ref, len(value))
+	return nil
+}
+
+// SecretDelete deletes the secret behind a reference.
+type SecretDelete struct {
+	Ref string `arg:"" help:"The secret:// reference" json:"ref"`
+}
+
+// Run deletes the secret.
+//
+// Inputs:
+//   - cmd: *SecretDelete. The reference.
+//
+// Outputs:
+//   - err: error. Non-nil if the backend fails.
+func (cmd *SecretDelete) Run() error {
+	if err := secret.Delete(context.Background(), cmd.Ref); err != nil {
+		Logger.Sugar().Errorf("failed to delete secret: %v", err)
+		return err
+	}
+	return nil
+}
+
+// readSecretInput reads a secret from the terminal without echo, or all of stdin when it is piped.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - []byte. The secret.
+//   - err: error. Non-nil if reading fails or the secret is empty.
+func readSecretInput() ([]byte, error) {
+	var value string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		var err error
+		value, err = promptSecret("Secret: ", "")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		piped, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		value = strings.TrimRight(string(piped), "\r\n")
+	}
+	if value == "" {
+		return nil, errors.New("secret is empty")
+	}
+	return []byte(value), nil
+}
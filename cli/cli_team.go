@@ -0,0 +1,611 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Team manages teams, invitations, members, realm bindings, and conflux membership.
+type Team struct {
+	Create       TeamCreate       `cmd:"create" help:"Create a team in an organisation"`
+	List         TeamList         `cmd:"list" help:"List teams"`
+	Get          TeamGet          `cmd:"get" help:"Show a team"`
+	Update       TeamUpdate       `cmd:"update" help:"Update a team"`
+	Delete       TeamDelete       `cmd:"delete" help:"Delete a team"`
+	Invite       TeamInvite       `cmd:"invite" help:"Invite users to a team by email"`
+	Invitations  TeamInvitations  `cmd:"invitations" help:"List received or sent team invitations"`
+	Accept       TeamAccept       `cmd:"accept" help:"Accept a team invitation"`
+	Reject       TeamReject       `cmd:"reject" help:"Reject a team invitation"`
+	Uninvite     TeamUninvite     `cmd:"uninvite" help:"Withdraw a sent team invitation"`
+	Members      TeamMembers      `cmd:"members" help:"List the members of a team"`
+	RemoveMember TeamRemoveMember `cmd:"remove-member" help:"Remove a member from a team"`
+	Bind         TeamBind         `cmd:"bind" help:"Bind a team to a realm"`
+	Unbind       TeamUnbind       `cmd:"unbind" help:"Unbind a team from its realm"`
+	Attach       TeamAttach       `cmd:"attach" help:"Attach a conflux to a team"`
+	Detach       TeamDetach       `cmd:"detach" help:"Detach a conflux from a team"`
+}
+
+// TeamCreate creates a team in an organisation.
+type TeamCreate struct {
+	Name   string `arg:"" help:"The team name"`
+	Org    string `required:"" help:"The organisation ID" env:"VEILNET_ORG_ID" json:"org"`
+	Email  string `help:"The team contact email" json:"email"`
+	Realm  string `short:"r" help:"The realm ID to bind the team to" json:"realm"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run creates the team and prints it.
+//
+// Inputs:
+//   - cmd: *TeamCreate. Name, organisation, email, realm, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamCreate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	team, err := client.CreateTeam(ctx, cmd.Org, &guardian.CreateTeamRequest{
+		Name:    cmd.Name,
+		Email:   cmd.Email,
+		RealmID: cmd.Realm,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create team: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(team)
+	}
+	printTeam(team)
+	return nil
+}
+
+// TeamList lists teams with optional organisation and realm filters.
+type TeamList struct {
+	Org    string `help:"Only show teams in this organisation ID" json:"org"`
+	Realm  string `short:"r" help:"Only show teams bound to this realm ID" json:"realm"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists teams.
+//
+// Inputs:
+//   - cmd: *TeamList. Filters and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	teams, err := client.ListTeams(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list teams: %v", err)
+		return err
+	}
+
+	filtered := make([]guardian.Team, 0, len(teams))
+	for _, team := range teams {
+		if cmd.Org != "" && team.OrganisationID != cmd.Org {
+			continue
+		}
+		if cmd.Realm != "" && team.RealmID != cmd.Realm {
+			continue
+		}
+		filtered = append(filtered, team)
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(filtered)
+	}
+	t := newTable("ID", "NAME", "ORGANISATION ID", "REALM ID", "EMAIL")
+	for _, team := range filtered {
+		t.Row(team.ID, team.Name, team.OrganisationID, team.RealmID, team.Email)
+	}
+	return t.Flush()
+}
+
+// TeamGet shows a team by ID.
+type TeamGet struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run fetches and prints the team.
+//
+// Inputs:
+//   - cmd: *TeamGet. Team ID and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamGet) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	team, err := client.GetTeam(ctx, cmd.TeamID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to get team: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(team)
+	}
+	printTeam(team)
+	return nil
+}
+
+// TeamUpdate changes a team's name or email.
+type TeamUpdate struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+	Name   string `help:"The new team name" json:"name"`
+	Email  string `help:"The new team contact email" json:"email"`
+}
+
+// Run updates the team; unset flags are left unchanged.
+//
+// Inputs:
+//   - cmd: *TeamUpdate. Team ID and new values.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamUpdate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	team, err := client.UpdateTeam(ctx, cmd.TeamID, &guardian.UpdateTeamRequest{
+		Name:  cmd.Name,
+		Email: cmd.Email,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to update team: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("updated team %s (%s)", team.Name, team.ID)
+	return nil
+}
+
+// TeamDelete deletes a team.
+type TeamDelete struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+}
+
+// Run deletes the team.
+//
+// Inputs:
+//   - cmd: *TeamDelete. The team ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamDelete) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.DeleteTeam(ctx, cmd.TeamID); err != nil {
+		Logger.Sugar().Errorf("failed to delete team: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("deleted team %s", cmd.TeamID)
+	return nil
+}
+
+// TeamInvite invites users to a team by email.
+type TeamInvite struct {
+	TeamID string   `arg:"" name:"team-id" help:"The team ID"`
+	Emails []string `arg:"" name:"email" help:"The emails of the users to invite"`
+}
+
+// Run sends an invitation to each email, continuing past failures and returning the last error.
+//
+// Inputs:
+//   - cmd: *TeamInvite. Team ID and emails.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or any invitation fails.
+func (cmd *TeamInvite) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	var lastErr error
+	for _, email := range cmd.Emails {
+		err := client.InviteTeamMember(ctx, &guardian.InviteTeamMemberRequest{
+			TeamID: cmd.TeamID,
+			Email:  email,
+		})
+		if err != nil {
+			Logger.Sugar().Errorf("failed to invite %s: %v", email, err)
+			lastErr = err
+			continue
+		}
+		Logger.Sugar().Infof("invited %s to team %s", email, cmd.TeamID)
+	}
+	return lastErr
+}
+
+// TeamInvitations lists team invitations received by, or sent by, the user.
+type TeamInvitations struct {
+	Sent   bool   `help:"List invitations you sent instead of those you received" json:"sent"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists invitations.
+//
+// Inputs:
+//   - cmd: *TeamInvitations. Direction and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamInvitations) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	var invitations []guardian.TeamInvitation
+	if cmd.Sent {
+		invitations, err = client.ListSentInvitations(ctx)
+	} else {
+		invitations, err = client.ListReceivedInvitations(ctx)
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list invitations: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(invitations)
+	}
+	t := newTable("ID", "ORGANISATION", "TEAM", "FROM", "TO", "STATUS")
+	for _, invitation := range invitations {
+		t.Row(invitation.ID, invitation.OrganisationName, invitation.TeamName, invitation.UserEmail, invitation.InvitedUserEmail, invitation.Status)
+	}
+	return t.Flush()
+}
+
+// TeamAccept accepts a team invitation.
+type TeamAccept struct {
+	InvitationID string `arg:"" name:"invitation-id" help:"The invitation ID (see: conflux team invitations)"`
+}
+
+// Run accepts the invitation.
+//
+// Inputs:
+//   - cmd: *TeamAccept. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamAccept) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.AcceptTeamInvitation(ctx, cmd.InvitationID); err != nil {
+		Logger.Sugar().Errorf("failed to accept invitation: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("accepted invitation %s", cmd.InvitationID)
+	return nil
+}
+
+// TeamReject rejects a team invitation.
+type TeamReject struct {
+	InvitationID string `arg:"" name:"invitation-id" help:"The invitation ID (see: conflux team invitations)"`
+}
+
+// Run rejects the invitation.
+//
+// Inputs:
+//   - cmd: *TeamReject. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamReject) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.RejectTeamInvitation(ctx, cmd.InvitationID); err != nil {
+		Logger.Sugar().Errorf("failed to reject invitation: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("rejected invitation %s", cmd.InvitationID)
+	return nil
+}
+
+// TeamUninvite withdraws a sent team invitation.
+type TeamUninvite struct {
+	InvitationID string `arg:"" name:"invitation-id" help:"The invitation ID (see: conflux team invitations --sent)"`
+}
+
+// Run deletes the invitation.
+//
+// Inputs:
+//   - cmd: *TeamUninvite. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamUninvite) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.DeleteTeamInvitation(ctx, cmd.InvitationID); err != nil {
+		Logger.Sugar().Errorf("failed to withdraw invitation: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("withdrew invitation %s", cmd.InvitationID)
+	return nil
+}
+
+// TeamMembers lists the members of a team.
+type TeamMembers struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists the members.
+//
+// Inputs:
+//   - cmd: *TeamMembers. Team ID and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamMembers) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	members, err := client.ListTeamMembers(ctx, cmd.TeamID)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list team members: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(members)
+	}
+	t := newTable("USER ID", "EMAIL", "NAME", "JOINED")
+	for _, member := range members {
+		joined := ""
+		if member.CreatedAt != nil {
+			joined = formatTime(*member.CreatedAt)
+		}
+		t.Row(member.UserID, member.Email, member.DisplayName, joined)
+	}
+	return t.Flush()
+}
+
+// TeamRemoveMember removes a member from a team.
+type TeamRemoveMember struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+	UserID string `arg:"" name:"user-id" help:"The member's user ID (see: conflux team members)"`
+}
+
+// Run removes the member.
+//
+// Inputs:
+//   - cmd: *TeamRemoveMember. Team and user IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamRemoveMember) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.RemoveTeamMember(ctx, cmd.TeamID, cmd.UserID); err != nil {
+		Logger.Sugar().Errorf("failed to remove team member: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("removed user %s from team %s", cmd.UserID, cmd.TeamID)
+	return nil
+}
+
+// TeamBind binds a team to a realm.
+type TeamBind struct {
+	TeamID  string `arg:"" name:"team-id" help:"The team ID"`
+	RealmID string `arg:"" name:"realm-id" help:"The realm ID"`
+}
+
+// Run binds the team to the realm.
+//
+// Inputs:
+//   - cmd: *TeamBind. Team and realm IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamBind) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	realmID := cmd.RealmID
+	err = client.UpdateTeamRealm(ctx, &guardian.UpdateTeamRealmRequest{
+		TeamID:  cmd.TeamID,
+		RealmID: &realmID,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to bind team to realm: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("bound team %s to realm %s", cmd.TeamID, cmd.RealmID)
+	return nil
+}
+
+// TeamUnbind unbinds a team from its realm.
+type TeamUnbind struct {
+	TeamID string `arg:"" name:"team-id" help:"The team ID"`
+}
+
+// Run clears the team's realm.
+//
+// Inputs:
+//   - cmd: *TeamUnbind. The team ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TeamUnbind) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	if err := client.UpdateTeamRealm(ctx, &guardian.UpdateTeamRealmRequest{TeamID: cmd.TeamID}); err != nil {
+		Logger.Sugar().Errorf("failed to unbind team: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("unbound team %s from its realm", cmd.TeamID)
+	return nil
+}
+
+// TeamAttach attaches a conflux to a team.
+type TeamAttach struct {
+	TeamID  string `arg:"" name:"team-id" help:"The team ID"`
+	Conflux string `short:"c" help:"The conflux ID, default: the local conflux" json:"conflux"`
+}
+
+// Run attaches the conflux to the team.
+//
+// Inputs:
+//   - cmd: *TeamAttach. Team ID and conflux ID.
+//
+// Outputs:
+//   - err: error. Non-nil if no conflux ID is known, the session is missing, or the request fails.
+func (cmd *TeamAttach) Run() error {
+	confluxID, err := confluxIDOrLocal(cmd.Conflux)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to attach conflux: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	err = client.AddConfluxTeam(ctx, &guardian.AddConfluxTeamRequest{
+		ConfluxID: confluxID,
+		TeamID:    cmd.TeamID,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to attach conflux to team: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("attached conflux %s to team %s", confluxID, cmd.TeamID)
+	return nil
+}
+
+// TeamDetach detaches a conflux from a team.
+type TeamDetach struct {
+	TeamID  string `arg:"" name:"team-id" help:"The team ID"`
+	Conflux string `short:"c" help:"The conflux ID, default: the local conflux" json:"conflux"`
+}
+
+// Run detaches the conflux from the team.
+//
+// Inputs:
+//   - cmd: *TeamDetach. Team ID and conflux ID.
+//
+// Outputs:
+//   - err: error. Non-nil if no conflux ID is known, the session is missing, or the request fails.
+func (cmd *TeamDetach) Run() error {
+	confluxID, err := confluxIDOrLocal(cmd.Conflux)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to detach conflux: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	err = client.RemoveConfluxTeam(ctx, &guardian.RemoveConfluxTeamRequest{
+		ConfluxID: confluxID,
+		TeamID:    cmd.TeamID,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to detach conflux from team: %v", err)
+		return err
+	}
+	Logger.Sugar().Infof("detached conflux %s from team %s", confluxID, cmd.TeamID)
+	return nil
+}
+
+// confluxIDOrLocal returns confluxID, or the local conflux ID if it is empty.
+func confluxIDOrLocal(confluxID string) (string, error) {
+	if confluxID != "" {
+		return confluxID, nil
+	}
+	local := localConfig()
+	if local == nil || local.ConfluxID == "" {
+		return "", errors.New("no conflux ID given and this host is not registered")
+	}
+	return local.ConfluxID, nil
+}
+
+// printTeam prints a team in the info layout.
+func printTeam(team *guardian.Team) {
+	fmt.Println("Team Info")
+	fmt.Println("---------")
+	fmt.Printf("  %-14s %s\n", "ID:", team.ID)
+	fmt.Printf("  %-14s %s\n", "Name:", team.Name)
+	fmt.Printf("  %-14s %s\n", "Organisation:", team.OrganisationID)
+	fmt.Printf("  %-14s %s\n", "Realm ID:", team.RealmID)
+	fmt.Printf("  %-14s %s\n", "Email:", team.Email)
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Token creates, lists, and revokes registration tokens via create/list/revoke subcommands.
+type Token struct {
+	Create TokenCreate `cmd:"create" help:"Create a registration token for a realm"`
+	List   TokenList   `cmd:"list" help:"List registration tokens"`
+	Revoke TokenRevoke `cmd:"revoke" help:"Revoke registration tokens"`
+}
+
+// TokenCreate creates a registration token for a realm with an expiry and optional tag.
+type TokenCreate struct {
+	Realm   string        `short:"r" required:"" help:"The realm ID the token registers confluxes into" env:"VEILNET_REALM_ID" json:"realm"`
+	Expires time.Duration `short:"e" help:"How long the token stays valid, default: 24h" default:"24h" json:"expires"`
+	Tag     string        `help:"The tag assigned to confluxes registered with this token" json:"tag"`
+	Output  string        `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run creates the token and prints it; the secret is only shown once.
+//
+// Inputs:
+//   - cmd: *TokenCreate. Realm, expiry, tag, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TokenCreate) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	token, err := client.CreateRegistrationToken(ctx, &guardian.CreateRegistrationTokenRequest{
+		RealmID:      cmd.Realm,
+		ExpiresAfter: int(cmd.Expires.Seconds()),
+		Tag:          cmd.Tag,
+	})
+	if err != nil {
+		Logger.Sugar().Errorf("failed to create registration token: %v", err)
+		return err
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(token)
+	}
+	t := newTable("TOKEN ID", "TOKEN", "EXPIRES")
+	t.Row(token.TokenID, token.Token, formatTime(time.Now().Add(cmd.Expires)))
+	return t.Flush()
+}
+
+// TokenList lists the user's registration tokens.
+type TokenList struct {
+	Realm  string `short:"r" help:"Only show tokens for this realm ID" json:"realm"`
+	Output string `short:"o" help:"Output format: table or json" enum:"table,json" default:"table" json:"output"`
+}
+
+// Run lists registration tokens, optionally filtered by realm.
+//
+// Inputs:
+//   - cmd: *TokenList. Realm filter and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *TokenList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	tokens, err := client.ListRegistrationTokens(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list registration tokens: %v", err)
+		return err
+	}
+
+	filtered := make([]guardian.RegistrationTokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		if cmd.Realm == "" || token.RealmID == cmd.Realm {
+			filtered = append(filtered, token)
+		}
+	}
+
+	if cmd.Output == "json" {
+		return printJSON(filtered)
+	}
+	t := newTable("TOKEN ID", "REALM ID", "TAG", "CREATED", "EXPIRES", "STATUS")
+	for _, token := range filtered {
+		status := "active"
+		switch {
+		case token.ExpiresAt.IsZero():
+			status = "unknown"
+		case time.Now().After(token.ExpiresAt.Time):
+			status = "expired"
+		}
+		t.Row(token.TokenID, token.RealmID, token.Tag, formatTimestamp(token.CreatedAt), formatTimestamp(token.ExpiresAt), status)
+	}
+	return t.Flush()
+}
+
+// TokenRevoke revokes one or more registration tokens by ID.
+type TokenRevoke struct {
+	TokenIDs []string `arg:"" name:"token-id" help:"The IDs of the tokens to revoke"`
+}
+
+// Run revokes each token, continuing past failures and returning the last error.
+//
+// Inputs:
+//   - cmd: *TokenRevoke. The token IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or any revocation fails.
+func (cmd *TokenRevoke) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	var lastErr error
+	for _, tokenID := range cmd.TokenIDs {
+		if err := client.RevokeRegistrationToken(ctx, tokenID); err != nil {
+			Logger.Sugar().Errorf("failed to revoke registration token %s: %v", tokenID, err)
+			lastErr = err
+			continue
+		}
+		Logger.Sugar().Infof("revoked registration token %s", tokenID)
+	}
+	return lastErr
+}
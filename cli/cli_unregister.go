@@ -1,19 +1,29 @@
 package cli
 
 import (
-	"github.com/veil-net/conflux/service"
+	"context"
+	"errors"
+
 	"github.com/veil-net/conflux/anchor"
+	"github.com/veil-net/conflux/guardian"
+	"github.com/veil-net/conflux/service"
 )
 
-// Unregister unregisters the conflux and removes the service; requires the registration token.
+// Unregister unregisters the conflux and removes the service.
+// A rift conflux authenticates with its own conflux token: its session logout (POST /conflux/session/logout) also
+// deletes it. Guardian keeps a portal conflux on logout and has no other endpoint that deletes a conflux with its own
+// token, so a portal needs the logged-in user session (DELETE /conflux) or, last, a registration token
+// (DELETE /conflux/unregister); rift confluxes fall back to them as well.
 type Unregister struct {
-	RegistrationToken string `short:"t" help:"The registration token" env:"VEILNET_REGISTRATION_TOKEN" json:"registration_token"`
+	RegistrationToken string `short:"t" help:"The registration token, only used when neither the conflux token (rift only) nor the user session can delete the conflux" env:"VEILNET_REGISTRATION_TOKEN" json:"registration_token"`
+	Force             bool   `help:"Remove the local config and service even if Guardian does not confirm the conflux was deleted, e.g. because it cannot be reached or every credential is rejected" json:"force"`
 }
 
 // Run unregisters the conflux with the guardian, deletes config, and removes the service.
+// Local state is only removed once Guardian deleted the conflux or no longer knows it, unless Force is set.
 //
 // Inputs:
-//   - cmd: *Unregister. The registration token.
+//   - cmd: *Unregister. The optional fallback registration token and force flag.
 //
 // Outputs:
 //   - err: error. Non-nil if any step fails.
@@ -27,11 +37,14 @@ func (cmd *Unregister) Run() error {
 	}
 
 	// Unregister the conflux
-	err = anchor.UnregisterConflux(cmd.RegistrationToken, config)
-	if err != nil {
-		Logger.Sugar().Errorf("failed to unregister conflux: %v", err)
+	err = cmd.unregister(context.Background(), config)
+	if err != nil && !cmd.Force {
+		Logger.Sugar().Errorf("failed to unregister conflux, nothing was removed locally (pass --force to remove it anyway): %v", err)
 		return err
 	}
+	if err != nil {
+		Logger.Sugar().Warnf("failed to unregister conflux, removing it locally because of --force: %v", err)
+	}
 
 	// Delete the configuration
 	err = anchor.DeleteConfig()
@@ -50,3 +63,76 @@ func (cmd *Unregister) Run() error {
 
 	return nil
 }
+
+// unregister removes the conflux from Guardian, trying each credential in turn.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - config: *anchor.ConfluxConfig. The local conflux config.
+//
+// Outputs:
+//   - err: error. Nil once Guardian deleted the conflux or answers that it does not know it; non-nil if every
+//     credential is rejected or a request fails for another reason.
+func (cmd *Unregister) unregister(ctx context.Context, config *anchor.ConfluxConfig) error {
+	// The conflux's own token, whose session logout deletes a rift conflux
+	if config.Rift && config.Token != "" {
+		err := guardian.NewClient(config.Guardian).WithConfluxToken(config.Token).ConfluxSessionLogout(ctx)
+		if done, err := unregistered(config, err); done {
+			return err
+		}
+		Logger.Sugar().Infof("conflux token cannot delete the conflux (%v), trying the user session", err)
+	}
+
+	// The logged-in user session
+	if session, err := anchor.LoadSession(); err == nil && session.Guardian == config.Guardian {
+		client, err := userClient(ctx)
+		if err != nil {
+			Logger.Sugar().Warnf("failed to use the user session: %v", err)
+		} else {
+			_, err = client.DeleteConflux(ctx, config.ConfluxID)
+			if done, err := unregistered(config, err); done {
+				return err
+			}
+			Logger.Sugar().Infof("user session cannot delete the conflux (%v), falling back to a registration token", err)
+		}
+	}
+
+	// A registration token, as a last resort
+	token := cmd.RegistrationToken
+	if token == "" {
+		var err error
+		token, err = promptSecret("Registration token: ", "pass --registration-token or run `conflux login`")
+		if err != nil {
+			return err
+		}
+	}
+	if token == "" {
+		return errors.New("registration token is required")
+	}
+	err := anchor.UnregisterConflux(ctx, token, config)
+	_, err = unregistered(config, err)
+	return err
+}
+
+// unregistered classifies the result of an unregister attempt.
+//
+// Inputs:
+//   - config: *anchor.ConfluxConfig. The local conflux config.
+//   - err: error. The result of the attempt.
+//
+// Outputs:
+//   - done: bool. False if the credential was rejected and the next one should be tried.
+//   - err: error. Nil when Guardian deleted the conflux or no longer knows it, so a re-run succeeds.
+func unregistered(config *anchor.ConfluxConfig, err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case guardian.IsNotFound(err):
+		Logger.Sugar().Infof("%s does not know conflux %s, treating it as already unregistered", config.Guardian, config.ConfluxID)
+		return true, nil
+	case guardian.IsUnauthorized(err):
+		return false, err
+	default:
+		return true, err
+	}
+}
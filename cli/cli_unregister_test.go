@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/veil-net/conflux/anchor"
+	"github.com/veil-net/conflux/guardian"
+)
+
+func TestUnregisterCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		rift bool
+		// statuses answers each request by method and path; unlisted requests get 401
+		statuses map[string]int
+		calls    []string
+		wantErr  bool
+	}{
+		{"rift deletes itself with its session logout", true,
+			map[string]int{"POST /conflux/session/logout": 200},
+			[]string{"POST /conflux/session/logout"}, false},
+		{"rift falls back to the registration token", true,
+			map[string]int{"DELETE /conflux/unregister": 200},
+			[]string{"POST /conflux/session/logout", "DELETE /conflux/unregister"}, false},
+		{"portal never logs out", false,
+			map[string]int{"POST /conflux/session/logout": 200, "DELETE /conflux/unregister": 200},
+			[]string{"DELETE /conflux/unregister"}, false},
+		{"unknown conflux counts as unregistered", false,
+			map[string]int{"DELETE /conflux/unregister": 404},
+			[]string{"DELETE /conflux/unregister"}, false},
+		{"every credential rejected", true,
+			map[string]int{},
+			[]string{"POST /conflux/session/logout", "DELETE /conflux/unregister"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No user session is cached in an empty config directory
+			anchor.SetConfigDir(t.TempDir())
+			t.Cleanup(func() { anchor.SetConfigDir("") })
+
+			var calls []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := r.Method + " " + r.URL.Path
+				calls = append(calls, call)
+				status, ok := tt.statuses[call]
+				if !ok {
+					status = http.StatusUnauthorized
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			config := &anchor.ConfluxConfig{ConfluxID: "conflux-1", Token: "conflux-token", Guardian: server.URL, Rift: tt.rift}
+			cmd := &Unregister{RegistrationToken: "registration-token"}
+			err := cmd.unregister(context.Background(), config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unregister error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !guardian.IsUnauthorized(err) {
+				t.Errorf("unregister error = %v, want the rejection", err)
+			}
+			if !slices.Equal(calls, tt.calls) {
+				t.Errorf("calls = %q, want %q", calls, tt.calls)
+			}
+		})
+	}
+}
@@ -2,26 +2,27 @@ package cli
 
 import (
 	"context"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
+	"github.com/alecthomas/kong"
 	"github.com/veil-net/conflux/anchor"
-	pb "github.com/veil-net/conflux/proto"
+	"github.com/veil-net/conflux/secret"
 	"github.com/veil-net/conflux/service"
 )
 
-// Up starts the veilnet service with a conflux token; flags include conflux ID, token, guardian, rift/portal, IP, taints, and debug.
+// Up starts the veilnet service with a conflux token; flags include conflux ID, token, guardian, rift/portal, IP, taints, region/veil pins, and debug.
 type Up struct {
-	ConfluxID string   `short:"c" help:"The conflux ID, please keep it secret" env:"VEILNET_CONFLUX_ID" json:"conflux_id"`
-	Token     string   `short:"t" help:"The conflux token, please keep it secret" env:"VEILNET_CONFLUX_TOKEN" json:"conflux_token"`
-	Guardian  string   `help:"The Guardian URL (Authentication Server), default: https://guardian.veilnet.app" default:"https://guardian.veilnet.app" env:"VEILNET_GUARDIAN" json:"guardian"`
-	Rift      bool     `short:"r" help:"Enable rift mode, default: false" default:"false" env:"VEILNET_CONFLUX_RIFT" json:"rift"`
-	Portal    bool     `short:"p" help:"Enable portal mode, default: false" default:"false" env:"VEILNET_CONFLUX_PORTAL" json:"portal"`
-	IP        string   `help:"The IP of the conflux" env:"VEILNET_CONFLUX_IP" json:"ip"`
-	Taints    []string `help:"Taints for the conflux, conflux can only communicate with other conflux with taints that are either a super set or a subset" env:"VEILNET_CONFLUX_TAINTS" json:"taints"`
-	Debug     bool     `short:"d" help:"Enable debug mode, this will not install the service but run conflux directly" env:"VEILNET_CONFLUX_DEBUG" json:"debug"`
+	Config     kong.ConfigFlag `help:"Read flags from a JSON, YAML, or TOML file keyed like conflux_id or taints; flags and env vars override it" type:"existingfile"`
+	ConfluxID  string          `short:"c" help:"The conflux ID, please keep it secret" env:"VEILNET_CONFLUX_ID" json:"conflux_id"`
+	Token      string          `short:"t" help:"The conflux token, or a secret:// reference to it, please keep it secret" env:"VEILNET_CONFLUX_TOKEN" json:"conflux_token"`
+	TokenStore string          `help:"Keep the conflux token in this secret:// reference instead of secrets.json, which is private to the owner but not encrypted, default: a --token reference is read in place, and down leaves it alone since conflux did not create it" env:"VEILNET_CONFLUX_TOKEN_STORE" json:"token_store"`
+	Guardian   string          `help:"The Guardian URL (Authentication Server), default: https://guardian.veilnet.app" default:"https://guardian.veilnet.app" env:"VEILNET_GUARDIAN" json:"guardian"`
+	Rift       bool            `short:"r" help:"Enable rift mode, default: false" default:"false" env:"VEILNET_CONFLUX_RIFT" json:"rift"`
+	Portal     bool            `short:"p" help:"Enable portal mode, default: false" default:"false" env:"VEILNET_CONFLUX_PORTAL" json:"portal"`
+	IP         string          `help:"The IP of the conflux" env:"VEILNET_CONFLUX_IP" json:"ip"`
+	Taints     []string        `help:"Taints for the conflux, conflux can only communicate with other conflux with taints that are either a super set or a subset" env:"VEILNET_CONFLUX_TAINTS" json:"taints"`
+	Region     string          `help:"Pin the conflux to veils in this region; this detects and stops, it does not prevent: Guardian assigns the veil of the realm, so the anchor connects first and is stopped if that veil is elsewhere, and the service stays down instead of reconnecting. Serve the realm from an in-region veil (realm create --veil) to stay in region" env:"VEILNET_CONFLUX_REGION" json:"region"`
+	Veil       string          `help:"Pin the conflux to this veil host (see: conflux veil list); like --region, the anchor is stopped after it connected to another veil, not kept from connecting" env:"VEILNET_CONFLUX_VEIL" json:"veil"`
+	Debug      bool            `short:"d" help:"Enable debug mode, this will not install the service but run conflux directly" env:"VEILNET_CONFLUX_DEBUG" json:"debug"`
 }
 
 // Run saves config and either installs the service or runs the anchor in debug mode.
@@ -32,22 +33,49 @@ type Up struct {
 // Outputs:
 //   - err: error. Non-nil if config save, service install, or anchor start fails.
 func (cmd *Up) Run() error {
-	// Parse the config
+	// A token given as a reference stays in its backend, which belongs to the operator and is never deleted
+	token, err := secret.Resolve(context.Background(), cmd.Token)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to resolve conflux token: %v", err)
+		return err
+	}
+	tokenStore := cmd.TokenStore
+	if tokenStore == "" && secret.IsRef(cmd.Token) {
+		tokenStore = cmd.Token
+	}
+
+	// Parse the config; only a --token-store reference is conflux's own to delete on down
 	config := &anchor.ConfluxConfig{
-		ConfluxID: cmd.ConfluxID,
-		Token:     cmd.Token,
-		Guardian:  cmd.Guardian,
-		Rift:      cmd.Rift,
-		IP:        cmd.IP,
-		Taints:    cmd.Taints,
+		ConfluxID:     cmd.ConfluxID,
+		Token:         token,
+		TokenRef:      tokenStore,
+		OwnedTokenRef: cmd.TokenStore,
+		Guardian:      cmd.Guardian,
+		Rift:          cmd.Rift,
+		Portal:        cmd.Portal,
+		IP:            cmd.IP,
+		Taints:        cmd.Taints,
+		Region:        cmd.Region,
+		Veil:          cmd.Veil,
+	}
+
+	// Validate the configuration
+	if err := anchor.Validate(config); err != nil {
+		Logger.Sugar().Errorf("invalid configuration: %v", err)
+		return err
+	}
+	if err := anchor.CheckIdentity(config); err != nil {
+		Logger.Sugar().Errorf("refusing to start: %v", err)
+		return err
 	}
 
 	// Save the configuration
-	err := anchor.SaveConfig(config)
+	err = anchor.SaveConfig(config)
 	if err != nil {
 		Logger.Sugar().Errorf("failed to save configuration: %v", err)
 		return err
 	}
+	noteTokenStore(config.TokenRef)
 
 	if !cmd.Debug {
 		// Install the service
@@ -61,56 +89,6 @@ func (cmd *Up) Run() error {
 		return nil
 	}
 
-
-	// Initialize the anchor plugin
-	subprocess, err := anchor.NewAnchor()
-	if err != nil {
-		Logger.Sugar().Errorf("failed to initialize anchor subprocess: %v", err)
-		return err
-	}
-
-	// Wait for the subprocess to start
-	time.Sleep(1 * time.Second)
-
-	// Create a gRPC client connection
-	anchor, err := anchor.NewAnchorClient()
-	if err != nil {
-		subprocess.Process.Kill()
-		Logger.Sugar().Errorf("failed to create anchor gRPC client: %v", err)
-		return err
-	}
-
-	// Start the anchor
-	_, err = anchor.StartAnchor(context.Background(), &pb.StartAnchorRequest{
-		GuardianUrl: config.Guardian,
-		AnchorToken: config.Token,
-		Ip:          config.IP,
-		Rift:        config.Rift,
-		Portal:      config.Portal,
-	})
-	if err != nil {
-		subprocess.Process.Kill()
-		Logger.Sugar().Errorf("failed to start anchor: %v", err)
-		return err
-	}
-
-	// Add taints
-	for _, taint := range config.Taints {
-		_, err = anchor.AddTaint(context.Background(), &pb.AddTaintRequest{
-			Taint: taint,
-		})
-		if err != nil {
-			Logger.Sugar().Warnf("failed to add taint: %v", err)
-			continue
-		}
-	}
-
-	// Wait for interrupt signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	// Wait for interrupt signal
-	<-interrupt
-
-	return nil
+	// Run the anchor in the foreground the way the service does
+	return service.RunDebug(config)
 }
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Veil lists the veils a conflux can be served by.
+type Veil struct {
+	List VeilList `cmd:"list" default:"1" help:"List veils and probe their latency from this host"`
+}
+
+// veilEntry is a veil with the result of its latency probe.
+type veilEntry struct {
+	guardian.Veil
+	Reachable bool    `json:"reachable"`
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+	ProbeErr  string  `json:"probe_error,omitempty"`
+}
+
+// VeilList lists veils with an optional region filter and a TCP latency probe.
+type VeilList struct {
+	Region  string        `help:"Only show veils in this region" json:"region"`
+	NoProbe bool          `help:"Skip the latency probe" json:"no_probe"`
+	Timeout time.Duration `help:"Timeout of each latency probe, default: 2s" default:"2s" json:"timeout"`
+	Output  string        `short:"o" help:"Output format: table, json or yaml" enum:"table,json,yaml" default:"table" json:"output"`
+}
+
+// Run lists veils from Guardian, probes each one concurrently, and prints them fastest first.
+//
+// Inputs:
+//   - cmd: *VeilList. Region filter, probe settings, and output format.
+//
+// Outputs:
+//   - err: error. Non-nil if the session is missing or the request fails.
+func (cmd *VeilList) Run() error {
+	ctx := context.Background()
+	client, err := userClient(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to load session: %v", err)
+		return err
+	}
+
+	veils, err := client.ListVeils(ctx)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list veils: %v", err)
+		return err
+	}
+
+	entries := make([]veilEntry, 0, len(veils))
+	for _, veil := range veils {
+		if cmd.Region != "" && !strings.EqualFold(veil.Region, cmd.Region) {
+			continue
+		}
+		entries = append(entries, veilEntry{Veil: veil})
+	}
+
+	if !cmd.NoProbe {
+		var wg sync.WaitGroup
+		for i := range entries {
+			wg.Add(1)
+			go func(entry *veilEntry) {
+				defer wg.Done()
+				latency, err := probeVeil(ctx, entry.Host, entry.Port, cmd.Timeout)
+				if err != nil {
+					entry.ProbeErr = err.Error()
+					return
+				}
+				entry.Reachable = true
+				entry.LatencyMS = float64(latency.Microseconds()) / 1000
+			}(&entries[i])
+		}
+		wg.Wait()
+
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Reachable != entries[j].Reachable {
+				return entries[i].Reachable
+			}
+			return entries[i].LatencyMS < entries[j].LatencyMS
+		})
+	}
+
+	if cmd.Output != "table" {
+		return printStructured(cmd.Output, entries)
+	}
+	t := newTable("ID", "NAME", "REGION", "HOST", "PORT", "LATENCY")
+	for _, entry := range entries {
+		latency := ""
+		switch {
+		case entry.Reachable:
+			latency = fmt.Sprintf("%.1f ms", entry.LatencyMS)
+		case entry.ProbeErr != "":
+			latency = "unreachable"
+		}
+		t.Row(entry.ID, entry.Name, entry.Region, entry.Host, strconv.Itoa(entry.Port), latency)
+	}
+	return t.Flush()
+}
+
+// probeVeil measures the TCP connect time to a veil.
+//
+// Inputs:
+//   - ctx: context.Context. Probe context.
+//   - host: string. Veil host.
+//   - port: int. Veil port.
+//   - timeout: time.Duration. Dial timeout.
+//
+// Outputs:
+//   - time.Duration. The connect time.
+//   - err: error. Non-nil if the veil cannot be reached.
+func probeVeil(ctx context.Context, host string, port int, timeout time.Duration) (time.Duration, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	conn.Close()
+	return latency, nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigLoader is the kong configuration loader behind --config. The file is a JSON, YAML, or TOML object keyed by
+// the json tags of the command's flags, e.g. conflux_id, guardian, or taints; flags and env vars override it.
+//
+// Inputs:
+//   - r: io.Reader. The config file content.
+//
+// Outputs:
+//   - resolver: kong.Resolver. Resolves flag values from the file.
+//   - err: error. Non-nil if the file is not a JSON, YAML, or TOML object.
+func ConfigLoader(r io.Reader) (kong.Resolver, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decodeConfigFile(content)
+	if err != nil {
+		return nil, err
+	}
+	return configFileResolver(values), nil
+}
+
+// decodeConfigFile decodes a TOML document, or a YAML one, which also covers JSON.
+func decodeConfigFile(content []byte) (map[string]any, error) {
+	values := map[string]any{}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return values, nil
+	}
+	if _, err := toml.Decode(string(content), &values); err == nil {
+		return values, nil
+	}
+	values = map[string]any{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("config file is not a JSON, YAML, or TOML object: %v", err)
+	}
+	return values, nil
+}
+
+// configFileResolver resolves flags from a decoded config file by their json tag.
+type configFileResolver map[string]any
+
+// Validate rejects keys that are not the json tag of any flag, so typos do not pass silently.
+//
+// Inputs:
+//   - app: *kong.Application. The CLI model.
+//
+// Outputs:
+//   - err: error. Non-nil if the file has unknown keys.
+func (r configFileResolver) Validate(app *kong.Application) error {
+	known := map[string]bool{}
+	kong.Visit(app, func(node kong.Visitable, next kong.Next) error {
+		if flag, ok := node.(*kong.Flag); ok {
+			if key := configFileKey(flag); key != "" {
+				known[key] = true
+			}
+		}
+		return next(nil)
+	})
+	var unknown []string
+	for key := range r {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys in config file: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Resolve returns the file's value for a flag, or nil if the file does not set it or one of its env vars is set.
+//
+// Inputs:
+//   - context: *kong.Context. The parse context.
+//   - parent: *kong.Path. The command the flag belongs to.
+//   - flag: *kong.Flag. The flag to resolve.
+//
+// Outputs:
+//   - value: any. The value, or nil to leave the flag alone.
+//   - err: error. Always nil.
+func (r configFileResolver) Resolve(context *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+	key := configFileKey(flag)
+	if key == "" {
+		return nil, nil
+	}
+	// Env vars take precedence over the file, as flags do
+	for _, env := range flag.Envs {
+		if _, ok := os.LookupEnv(env); ok {
+			return nil, nil
+		}
+	}
+	value, ok := r[key]
+	if !ok {
+		return nil, nil
+	}
+	return configFileValue(value), nil
+}
+
+// configFileKey returns the key a flag is read from, its json tag name, or "" if it has none.
+func configFileKey(flag *kong.Flag) string {
+	name, _, _ := strings.Cut(flag.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// configFileValue turns list items and scalars other than strings and bools into strings, which kong's mappers
+// accept, so YAML `conflux_id: 1234` still fills a string flag.
+func configFileValue(value any) any {
+	switch value := value.(type) {
+	case string, bool:
+		return value
+	case []any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return items
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+// upEnvs are the env vars of the up flags the tests read; they are cleared so the host environment does not leak in.
+var upEnvs = []string{"VEILNET_CONFLUX_ID", "VEILNET_CONFLUX_TOKEN", "VEILNET_GUARDIAN", "VEILNET_CONFLUX_RIFT", "VEILNET_CONFLUX_TAINTS"}
+
+// parseUp parses `up` with the given args against the real CLI model and the --config loader.
+func parseUp(t *testing.T, args ...string) (*Up, error) {
+	t.Helper()
+	var cli CLI
+	parser, err := kong.New(&cli, kong.Vars{"version": "test"}, kong.Configuration(ConfigLoader))
+	if err != nil {
+		t.Fatalf("kong.New: %v", err)
+	}
+	_, err = parser.Parse(append([]string{"up"}, args...))
+	return &cli.Up, err
+}
+
+// writeConfigFile writes content to a file named name in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func clearUpEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range upEnvs {
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+}
+
+func TestConfigFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"json", "up.json", `{"conflux_id": "from-file", "rift": true, "taints": ["a", "b"], "guardian": "https://guardian.test"}`},
+		{"yaml", "up.yaml", "conflux_id: from-file\nrift: true\ntaints: [a, b]\nguardian: https://guardian.test\n"},
+		{"toml", "up.toml", "conflux_id = \"from-file\"\nrift = true\ntaints = [\"a\", \"b\"]\nguardian = \"https://guardian.test\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearUpEnv(t)
+			up, err := parseUp(t, "--config", writeConfigFile(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if up.ConfluxID != "from-file" || !up.Rift || up.Guardian != "https://guardian.test" {
+				t.Errorf("up = %+v, want conflux_id, rift, and guardian from the file", up)
+			}
+			if !slices.Equal(up.Taints, []string{"a", "b"}) {
+				t.Errorf("taints = %q, want [a b]", up.Taints)
+			}
+		})
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	path := writeConfigFile(t, "up.yaml", "conflux_id: from-file\nconflux_token: file-token\ntaints: [file]\n")
+	tests := []struct {
+		name   string
+		env    map[string]string
+		args   []string
+		id     string
+		token  string
+		taints []string
+	}{
+		{"file only", nil, nil, "from-file", "file-token", []string{"file"}},
+		{"env overrides file", map[string]string{"VEILNET_CONFLUX_ID": "from-env", "VEILNET_CONFLUX_TAINTS": "env"}, nil,
+			"from-env", "file-token", []string{"env"}},
+		{"empty env still overrides file", map[string]string{"VEILNET_CONFLUX_TOKEN": ""}, nil, "from-file", "", []string{"file"}},
+		{"flag overrides file", nil, []string{"--conflux-id", "from-flag"}, "from-flag", "file-token", []string{"file"}},
+		{"flag overrides env", map[string]string{"VEILNET_CONFLUX_ID": "from-env"}, []string{"--conflux-id", "from-flag"},
+			"from-flag", "file-token", []string{"file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearUpEnv(t)
+			for env, value := range tt.env {
+				t.Setenv(env, value)
+			}
+			up, err := parseUp(t, append([]string{"--config", path}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if up.ConfluxID != tt.id || up.Token != tt.token || !slices.Equal(up.Taints, tt.taints) {
+				t.Errorf("conflux_id, token, taints = %q, %q, %q, want %q, %q, %q", up.ConfluxID, up.Token, up.Taints, tt.id, tt.token, tt.taints)
+			}
+		})
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "conflux_id: x\nconflux_idd: y\n", "unknown keys in config file: conflux_idd"},
+		{"not an object", "- a\n- b\n", "config file is not a JSON, YAML, or TOML object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearUpEnv(t)
+			_, err := parseUp(t, "--config", writeConfigFile(t, "up.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFileValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{"x", "x"},
+		{true, true},
+		{int64(1234), "1234"},
+		{1.5, "1.5"},
+		{[]any{"a", int64(2)}, []any{"a", "2"}},
+	}
+	for _, tt := range tests {
+		got := configFileValue(tt.in)
+		if items, ok := got.([]any); ok {
+			if !slices.Equal(items, tt.want.([]any)) {
+				t.Errorf("configFileValue(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("configFileValue(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/veil-net/conflux/guardian"
+	"gopkg.in/yaml.v3"
+)
+
+// printJSON writes v to stdout as indented JSON.
+//
+// Inputs:
+//   - v: any. The value to print.
+//
+// Outputs:
+//   - err: error. Non-nil if v cannot be encoded.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printYAML writes v to stdout as YAML, using the same field names and order as its JSON encoding.
+//
+// Inputs:
+//   - v: any. The value to print.
+//
+// Outputs:
+//   - err: error. Non-nil if v cannot be encoded.
+func printYAML(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so decoding it into a node keeps the key order of the JSON encoding
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetYAMLStyle clears the flow/quoted styles inherited from JSON so the output uses block style.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// printStructured writes v as JSON or YAML depending on format.
+//
+// Inputs:
+//   - format: string. "json" or "yaml".
+//   - v: any. The value to print.
+//
+// Outputs:
+//   - err: error. Non-nil if v cannot be encoded or the format is unknown.
+func printStructured(format string, v any) error {
+	switch format {
+	case "json":
+		return printJSON(v)
+	case "yaml":
+		return printYAML(v)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// table writes tab-aligned rows to stdout.
+type table struct {
+	writer *tabwriter.Writer
+}
+
+// newTable returns a table with the given column headers already written.
+//
+// Inputs:
+//   - headers: ...string. Column headers.
+//
+// Outputs:
+//   - *table. The table; call Flush when done.
+func newTable(headers ...string) *table {
+	t := &table{writer: tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)}
+	t.Row(headers...)
+	return t
+}
+
+// Row writes a single row; empty cells are shown as "-".
+func (t *table) Row(cells ...string) {
+	for i, cell := range cells {
+		if cell == "" {
+			cells[i] = "-"
+		}
+	}
+	fmt.Fprintln(t.writer, strings.Join(cells, "\t"))
+}
+
+// Flush writes the buffered rows to stdout.
+func (t *table) Flush() error {
+	return t.writer.Flush()
+}
+
+// formatTime formats t for table output, or returns "" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// formatTimestamp formats a Guardian timestamp like formatTime, or returns the string Guardian sent if it was unreadable.
+func formatTimestamp(t guardian.Timestamp) string {
+	if t.IsZero() {
+		return t.Raw
+	}
+	return formatTime(t.Time)
+}
@@ -2,7 +2,12 @@ module github.com/veil-net/conflux
 
 go 1.26.0
 
-require google.golang.org/grpc v1.79.1
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/term v0.40.0
+	google.golang.org/grpc v1.79.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/stretchr/testify v1.11.1 // indirect
@@ -0,0 +1,102 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Login authenticates a user with email and password (OAuth2 password grant).
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - username: string. The user's email.
+//   - password: string. The user's password.
+//
+// Outputs:
+//   - *LoginResponse. The access token.
+//   - err: error. Non-nil if authentication fails.
+func (c *Client) Login(ctx context.Context, username string, password string) (*LoginResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", username)
+	form.Set("password", password)
+	var resp LoginResponse
+	if err := c.doForm(ctx, http.MethodPost, "/auth/login", form, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetProfile returns the profile of the authenticated user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - *UserProfile. The user profile.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetProfile(ctx context.Context) (*UserProfile, error) {
+	var profile UserProfile
+	if err := c.doJSON(ctx, http.MethodGet, "/auth/profile", nil, nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateDisplayName changes the authenticated user's display name.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - displayName: string. The new display name.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateDisplayName(ctx context.Context, displayName string) error {
+	return c.doJSON(ctx, http.MethodPatch, "/auth/profile/display-name", nil, &UpdateDisplayNameRequest{DisplayName: displayName}, nil)
+}
+
+// CreateRegistrationToken creates a registration token for a realm.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *CreateRegistrationTokenRequest. Realm, lifetime, and optional tag.
+//
+// Outputs:
+//   - *RegistrationToken. The token ID and secret.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateRegistrationToken(ctx context.Context, req *CreateRegistrationTokenRequest) (*RegistrationToken, error) {
+	var token RegistrationToken
+	if err := c.doJSON(ctx, http.MethodPost, "/auth/create/registration-token", nil, req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeRegistrationToken revokes a registration token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - tokenID: string. The token ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RevokeRegistrationToken(ctx context.Context, tokenID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/auth/revoke/registration-token", url.Values{"token_id": {tokenID}}, nil, nil)
+}
+
+// ListRegistrationTokens lists the user's registration tokens.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []RegistrationTokenInfo. The tokens.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListRegistrationTokens(ctx context.Context) ([]RegistrationTokenInfo, error) {
+	var tokens []RegistrationTokenInfo
+	if err := c.doJSON(ctx, http.MethodGet, "/auth/list/registration-token", nil, nil, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -0,0 +1,248 @@
+// Package guardian provides a typed client for the VeilNet Guardian REST API (auth, realms, conflux, veils, orgs, teams, subscriptions, health).
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the public Guardian endpoint used when no URL is configured.
+const DefaultURL = "https://guardian.veilnet.app"
+
+// DefaultTimeout bounds a single HTTP attempt against Guardian.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxRetries is the number of extra attempts made for retryable failures.
+const DefaultMaxRetries = 3
+
+// DefaultRetryWait is the initial backoff between retries; it doubles on each attempt.
+const DefaultRetryWait = 500 * time.Millisecond
+
+// confluxTokenHeader is the header used by the APIKeyHeader security scheme.
+const confluxTokenHeader = "x-conflux-token"
+
+// Client is a Guardian API client. It is safe for concurrent use; the With* methods return copies.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	bearerToken  string
+	confluxToken string
+	maxRetries   int
+	retryWait    time.Duration
+}
+
+// NewClient returns a Guardian client for baseURL with default timeout and retry settings.
+//
+// Inputs:
+//   - baseURL: string. The Guardian URL; DefaultURL is used if empty.
+//
+// Outputs:
+//   - *Client. The Guardian client.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		maxRetries: DefaultMaxRetries,
+		retryWait:  DefaultRetryWait,
+	}
+}
+
+// BaseURL returns the Guardian URL the client talks to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// WithBearerToken returns a copy of the client that sends token as an Authorization bearer (user session or registration token).
+//
+// Inputs:
+//   - token: string. The bearer token.
+//
+// Outputs:
+//   - *Client. The derived client.
+func (c *Client) WithBearerToken(token string) *Client {
+	clone := *c
+	clone.bearerToken = token
+	return &clone
+}
+
+// WithConfluxToken returns a copy of the client that sends token in the x-conflux-token header.
+//
+// Inputs:
+//   - token: string. The conflux token.
+//
+// Outputs:
+//   - *Client. The derived client.
+func (c *Client) WithConfluxToken(token string) *Client {
+	clone := *c
+	clone.confluxToken = token
+	return &clone
+}
+
+// WithTimeout returns a copy of the client whose HTTP attempts are bounded by timeout.
+//
+// Inputs:
+//   - timeout: time.Duration. Per-attempt timeout.
+//
+// Outputs:
+//   - *Client. The derived client.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	clone := *c
+	clone.httpClient = &http.Client{Timeout: timeout, Transport: c.httpClient.Transport}
+	return &clone
+}
+
+// WithRetries returns a copy of the client with the given retry count and initial backoff.
+//
+// Inputs:
+//   - maxRetries: int. Extra attempts for retryable failures; 0 disables retries.
+//   - wait: time.Duration. Initial backoff, doubled after each attempt.
+//
+// Outputs:
+//   - *Client. The derived client.
+func (c *Client) WithRetries(maxRetries int, wait time.Duration) *Client {
+	clone := *c
+	clone.maxRetries = maxRetries
+	clone.retryWait = wait
+	return &clone
+}
+
+// doJSON sends a JSON request and decodes a JSON response into out (if non-nil).
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - method, path: string. HTTP method and API path.
+//   - query: url.Values. Optional query parameters.
+//   - in: any. Optional request body, marshalled as JSON.
+//   - out: any. Optional response target.
+//
+// Outputs:
+//   - err: error. *APIError for non-2xx responses, or a transport/decoding error.
+func (c *Client) doJSON(ctx context.Context, method string, path string, query url.Values, in any, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	return c.do(ctx, method, path, query, "application/json", body, out)
+}
+
+// doForm sends a form-encoded request and decodes a JSON response into out (if non-nil).
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - method, path: string. HTTP method and API path.
+//   - form: url.Values. The form body.
+//   - out: any. Optional response target.
+//
+// Outputs:
+//   - err: error. *APIError for non-2xx responses, or a transport/decoding error.
+func (c *Client) doForm(ctx context.Context, method string, path string, form url.Values, out any) error {
+	return c.do(ctx, method, path, nil, "application/x-www-form-urlencoded", []byte(form.Encode()), out)
+}
+
+// do executes the request with retries and decodes the response.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, contentType string, body []byte, out any) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		respBody, status, err := c.attempt(ctx, method, endpoint, contentType, body)
+		if err == nil && status >= 200 && status < 300 {
+			if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+				return nil
+			}
+			if raw, ok := out.(*json.RawMessage); ok {
+				*raw = append((*raw)[:0], respBody...)
+				return nil
+			}
+			return json.Unmarshal(respBody, out)
+		}
+		if err == nil {
+			err = newAPIError(method, path, status, respBody)
+		}
+		if attempt >= c.maxRetries || !retryable(method, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// attempt performs a single HTTP round trip and returns the body and status code.
+func (c *Client) attempt(ctx context.Context, method string, endpoint string, contentType string, body []byte) ([]byte, int, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.bearerToken))
+	}
+	if c.confluxToken != "" {
+		req.Header.Set(confluxTokenHeader, c.confluxToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt should be retried.
+// Server errors are retried for idempotent methods; for POST and PATCH only gateway errors are retried,
+// since the request most likely never reached Guardian.
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	idempotent := method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead || method == http.MethodPut
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return idempotent && apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return idempotent
+	}
+	return false
+}
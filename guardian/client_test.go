@@ -0,0 +1,126 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer answers every request with the statuses in order, repeating the last one, and counts the attempts.
+func countingServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(attempts.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"id":"realm-1","name":"prod"}`))
+			return
+		}
+		w.Write([]byte(`{"detail":"try again"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		attempts int32
+		wantErr  int
+	}{
+		{"success", http.MethodGet, []int{200}, 1, 0},
+		{"get retried on server error", http.MethodGet, []int{500, 500, 200}, 3, 0},
+		{"get gives up after max retries", http.MethodGet, []int{500}, 3, 500},
+		{"post not retried on server error", http.MethodPost, []int{500, 200}, 1, 500},
+		{"post retried on bad gateway", http.MethodPost, []int{502, 503, 200}, 3, 0},
+		{"post retried on gateway timeout", http.MethodPost, []int{504, 200}, 2, 0},
+		{"client error not retried", http.MethodGet, []int{404, 200}, 1, 404},
+		{"validation error not retried", http.MethodPut, []int{422, 200}, 1, 422},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, attempts := countingServer(t, tt.statuses...)
+			client := NewClient(server.URL).WithRetries(2, time.Millisecond)
+
+			var realm Realm
+			err := client.doJSON(context.Background(), tt.method, "/realm", nil, nil, &realm)
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+			if got := StatusCode(err); got != tt.wantErr {
+				t.Fatalf("error = %v, want status %d", err, tt.wantErr)
+			}
+			if err == nil && realm.ID != "realm-1" {
+				t.Errorf("decoded realm = %+v, want realm-1", realm)
+			}
+		})
+	}
+}
+
+func TestClientRetryStopsOnCancel(t *testing.T) {
+	server, attempts := countingServer(t, http.StatusServiceUnavailable)
+	client := NewClient(server.URL).WithRetries(5, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := client.doJSON(ctx, http.MethodGet, "/realm", nil, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestClientRetryOnNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	for _, tt := range []struct {
+		method    string
+		retryable bool
+	}{
+		{http.MethodGet, true},
+		{http.MethodPost, false},
+	} {
+		err := NewClient(endpoint).WithRetries(0, 0).doJSON(context.Background(), tt.method, "/realm", nil, nil, nil)
+		if err == nil {
+			t.Fatalf("%s to a closed server succeeded", tt.method)
+		}
+		if got := retryable(tt.method, err); got != tt.retryable {
+			t.Errorf("retryable(%s, %v) = %t, want %t", tt.method, err, got, tt.retryable)
+		}
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL).WithBearerToken("session").WithConfluxToken("conflux")
+	if err := client.doJSON(context.Background(), http.MethodPost, "/realm", nil, map[string]string{"name": "prod"}, nil); err != nil {
+		t.Fatalf("doJSON: %v", err)
+	}
+	if got.Get("Authorization") != "Bearer session" {
+		t.Errorf("Authorization = %q, want %q", got.Get("Authorization"), "Bearer session")
+	}
+	if got.Get(confluxTokenHeader) != "conflux" {
+		t.Errorf("%s = %q, want %q", confluxTokenHeader, got.Get(confluxTokenHeader), "conflux")
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Get("Content-Type"))
+	}
+}
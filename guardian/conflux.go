@@ -0,0 +1,249 @@
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	pb "github.com/veil-net/conflux/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreateConflux creates a conflux in a realm on behalf of the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *CreateConfluxRequest. Realm and optional tag.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateConflux(ctx context.Context, req *CreateConfluxRequest) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodPost, "/conflux", nil, req, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// GetConflux returns a conflux by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - confluxID: string. The conflux ID.
+//
+// Outputs:
+//   - *Conflux. The conflux.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetConflux(ctx context.Context, confluxID string) (*Conflux, error) {
+	var conflux Conflux
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux", url.Values{"conflux_id": {confluxID}}, nil, &conflux); err != nil {
+		return nil, err
+	}
+	return &conflux, nil
+}
+
+// DeleteConflux deletes a conflux by ID on behalf of the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - confluxID: string. The conflux ID.
+//
+// Outputs:
+//   - *Conflux. The deleted conflux.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteConflux(ctx context.Context, confluxID string) (*Conflux, error) {
+	var conflux Conflux
+	if err := c.doJSON(ctx, http.MethodDelete, "/conflux", url.Values{"conflux_id": {confluxID}}, nil, &conflux); err != nil {
+		return nil, err
+	}
+	return &conflux, nil
+}
+
+// RegisterConflux registers a new conflux; the client must carry a registration token as bearer.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *RegisterConfluxRequest. Tag, CIDR, teams, and optional IdP settings.
+//
+// Outputs:
+//   - *RegisterConfluxResponse. The conflux ID and token.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RegisterConflux(ctx context.Context, req *RegisterConfluxRequest) (*RegisterConfluxResponse, error) {
+	var resp RegisterConfluxResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/conflux/register", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UnregisterConflux unregisters a conflux; the client must carry a registration token as bearer.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - confluxID: string. The conflux ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UnregisterConflux(ctx context.Context, confluxID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/conflux/unregister", url.Values{"conflux_id": {confluxID}}, nil, nil)
+}
+
+// ListConflux lists the confluxes visible to the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []Conflux. The confluxes.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListConflux(ctx context.Context) ([]Conflux, error) {
+	var confluxes []Conflux
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux/list", nil, nil, &confluxes); err != nil {
+		return nil, err
+	}
+	return confluxes, nil
+}
+
+// ConfluxSessionLogin opens a conflux session; the client must carry a conflux token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *ConfluxSessionLoginRequest. Signature and portal mode.
+//
+// Outputs:
+//   - *Conflux. The conflux with its session certificate (CAPEM, CertPEM, KeyPEM).
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ConfluxSessionLogin(ctx context.Context, req *ConfluxSessionLoginRequest) (*Conflux, error) {
+	var conflux Conflux
+	if err := c.doJSON(ctx, http.MethodPost, "/conflux/session/login", nil, req, &conflux); err != nil {
+		return nil, err
+	}
+	return &conflux, nil
+}
+
+// ConfluxSessionLogout closes the conflux session; the client must carry a conflux token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ConfluxSessionLogout(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodPost, "/conflux/session/logout", nil, nil, nil)
+}
+
+// GetConfluxWebRTC returns the WebRTC settings for the conflux; the client must carry a conflux token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetConfluxWebRTC(ctx context.Context) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux/webrtc", nil, nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// AddConfluxTeam attaches a conflux to a team.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *AddConfluxTeamRequest. Conflux and team IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) AddConfluxTeam(ctx context.Context, req *AddConfluxTeamRequest) error {
+	return c.doJSON(ctx, http.MethodPost, "/conflux/team", nil, req, nil)
+}
+
+// RemoveConfluxTeam detaches a conflux from a team.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *RemoveConfluxTeamRequest. Conflux and team IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RemoveConfluxTeam(ctx context.Context, req *RemoveConfluxTeamRequest) error {
+	return c.doJSON(ctx, http.MethodDelete, "/conflux/team", nil, req, nil)
+}
+
+// ListConfluxTeams lists the teams of the conflux; the client must carry a conflux token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListConfluxTeams(ctx context.Context) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux/team/list", nil, nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// GetConfluxLocalNetworks returns the local networks (subnets) a conflux exposes.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - confluxID: string. The conflux ID.
+//
+// Outputs:
+//   - *pb.LocalNetworks. The exposed subnets.
+//   - err: error. Non-nil if the request fails or the response cannot be decoded.
+func (c *Client) GetConfluxLocalNetworks(ctx context.Context, confluxID string) (*pb.LocalNetworks, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux/local-network", url.Values{"conflux_id": {confluxID}}, nil, &raw); err != nil {
+		return nil, err
+	}
+	networks := &pb.LocalNetworks{}
+	if err := decodeNetworks(raw, "local_networks", networks); err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
+
+// GetConfluxRemoteNetworks returns the remote networks a conflux reaches and the peers exposing them.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - confluxID: string. The conflux ID.
+//
+// Outputs:
+//   - *pb.RemoteNetworks. The reachable subnets with their peer signatures.
+//   - err: error. Non-nil if the request fails or the response cannot be decoded.
+func (c *Client) GetConfluxRemoteNetworks(ctx context.Context, confluxID string) (*pb.RemoteNetworks, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/conflux/remote-network", url.Values{"conflux_id": {confluxID}}, nil, &raw); err != nil {
+		return nil, err
+	}
+	networks := &pb.RemoteNetworks{}
+	if err := decodeNetworks(raw, "remote_networks", networks); err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
+
+// decodeNetworks decodes a network list into its protobuf message.
+// Guardian may return either the message itself or a bare array of its repeated field.
+func decodeNetworks(raw json.RawMessage, field string, msg proto.Message) error {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if trimmed[0] == '[' {
+		trimmed = []byte(fmt.Sprintf(`{%q:%s}`, field, trimmed))
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(trimmed, msg)
+}
@@ -0,0 +1,123 @@
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ValidationError is a single field error reported by Guardian (FastAPI validation).
+type ValidationError struct {
+	Loc  []any  `json:"loc"`
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+}
+
+// Field returns the dotted location of the error, without the leading "body"/"query" segment.
+func (v ValidationError) Field() string {
+	parts := make([]string, 0, len(v.Loc))
+	for i, loc := range v.Loc {
+		if s, ok := loc.(string); ok && i == 0 && (s == "body" || s == "query" || s == "path" || s == "header") {
+			continue
+		}
+		parts = append(parts, fmt.Sprint(loc))
+	}
+	return strings.Join(parts, ".")
+}
+
+// HTTPValidationError is the 422 response body returned by Guardian.
+type HTTPValidationError struct {
+	Detail []ValidationError `json:"detail"`
+}
+
+// APIError is returned for any non-2xx Guardian response.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Detail is the plain error message, when Guardian returns {"detail": "..."}.
+	Detail string
+	// Validation holds the decoded field errors of a 422 response.
+	Validation *HTTPValidationError
+	// Body is the raw response body.
+	Body []byte
+}
+
+// Error formats the status, message, and any field errors.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "guardian %s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	switch {
+	case e.Validation != nil && len(e.Validation.Detail) > 0:
+		for i, v := range e.Validation.Detail {
+			if i == 0 {
+				b.WriteString(": ")
+			} else {
+				b.WriteString("; ")
+			}
+			if field := v.Field(); field != "" {
+				fmt.Fprintf(&b, "%s: ", field)
+			}
+			b.WriteString(v.Msg)
+		}
+	case e.Detail != "":
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	case len(e.Body) > 0:
+		fmt.Fprintf(&b, ": %s", strings.TrimSpace(string(e.Body)))
+	}
+	return b.String()
+}
+
+// newAPIError decodes a Guardian error body into an *APIError.
+func newAPIError(method string, path string, status int, body []byte) *APIError {
+	apiErr := &APIError{
+		Method:     method,
+		Path:       path,
+		StatusCode: status,
+		Body:       body,
+	}
+	var envelope struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Detail) == 0 {
+		return apiErr
+	}
+	var detail string
+	if err := json.Unmarshal(envelope.Detail, &detail); err == nil {
+		apiErr.Detail = detail
+		return apiErr
+	}
+	var validation []ValidationError
+	if err := json.Unmarshal(envelope.Detail, &validation); err == nil {
+		apiErr.Validation = &HTTPValidationError{Detail: validation}
+	}
+	return apiErr
+}
+
+// StatusCode returns the HTTP status of err if it is an *APIError, or 0 otherwise.
+//
+// Inputs:
+//   - err: error. The error to inspect.
+//
+// Outputs:
+//   - int. The HTTP status code, or 0.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a Guardian 404.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a Guardian 401 or 403.
+func IsUnauthorized(err error) bool {
+	code := StatusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
@@ -0,0 +1,74 @@
+package guardian
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		detail     string
+		validation []string
+		message    string
+	}{
+		{"detail string", http.StatusNotFound, `{"detail":"Realm not found"}`, "Realm not found", nil,
+			"guardian GET /realm: 404 Not Found: Realm not found"},
+		{"validation errors", http.StatusUnprocessableEntity,
+			`{"detail":[{"loc":["body","subnet"],"msg":"invalid CIDR","type":"value_error"},{"loc":["query","tags",0],"msg":"too long","type":"value_error"}]}`,
+			"", []string{"subnet", "tags.0"},
+			"guardian GET /realm: 422 Unprocessable Entity: subnet: invalid CIDR; tags.0: too long"},
+		{"validation error without location", http.StatusUnprocessableEntity, `{"detail":[{"loc":["body"],"msg":"body is required"}]}`,
+			"", []string{""}, "guardian GET /realm: 422 Unprocessable Entity: body is required"},
+		{"plain text body", http.StatusBadGateway, "upstream unavailable\n", "", nil,
+			"guardian GET /realm: 502 Bad Gateway: upstream unavailable"},
+		{"unknown detail shape", http.StatusBadRequest, `{"detail":{"code":1}}`, "", nil,
+			`guardian GET /realm: 400 Bad Request: {"detail":{"code":1}}`},
+		{"empty body", http.StatusInternalServerError, "", "", nil, "guardian GET /realm: 500 Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := newAPIError(http.MethodGet, "/realm", tt.status, []byte(tt.body))
+			if apiErr.Detail != tt.detail {
+				t.Errorf("Detail = %q, want %q", apiErr.Detail, tt.detail)
+			}
+			var fields []string
+			if apiErr.Validation != nil {
+				for _, v := range apiErr.Validation.Detail {
+					fields = append(fields, v.Field())
+				}
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.validation) {
+				t.Errorf("validation fields = %q, want %q", fields, tt.validation)
+			}
+			if got := apiErr.Error(); got != tt.message {
+				t.Errorf("Error() = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestStatusHelpers(t *testing.T) {
+	wrapped := fmt.Errorf("failed to get realm: %w", newAPIError(http.MethodGet, "/realm", http.StatusNotFound, nil))
+	if got := StatusCode(wrapped); got != http.StatusNotFound {
+		t.Errorf("StatusCode(wrapped 404) = %d, want 404", got)
+	}
+	if !IsNotFound(wrapped) {
+		t.Error("IsNotFound(wrapped 404) = false, want true")
+	}
+	if got := StatusCode(errors.New("dial tcp: refused")); got != 0 {
+		t.Errorf("StatusCode(non-API error) = %d, want 0", got)
+	}
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		if !IsUnauthorized(newAPIError(http.MethodGet, "/profile", status, nil)) {
+			t.Errorf("IsUnauthorized(%d) = false, want true", status)
+		}
+	}
+	if IsUnauthorized(newAPIError(http.MethodGet, "/profile", http.StatusNotFound, nil)) {
+		t.Error("IsUnauthorized(404) = true, want false")
+	}
+}
@@ -0,0 +1,17 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+)
+
+// Health checks that Guardian is reachable and healthy.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - err: error. Non-nil if Guardian is unreachable or unhealthy.
+func (c *Client) Health(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/health", nil, nil, nil)
+}
@@ -0,0 +1,106 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateOrganisation creates an organisation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *CreateOrganisationRequest. Name and optional website/email.
+//
+// Outputs:
+//   - *Organisation. The created organisation.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateOrganisation(ctx context.Context, req *CreateOrganisationRequest) (*Organisation, error) {
+	var org Organisation
+	if err := c.doJSON(ctx, http.MethodPost, "/org", nil, req, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganisation returns an organisation by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - organisationID: string. The organisation ID.
+//
+// Outputs:
+//   - *Organisation. The organisation.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetOrganisation(ctx context.Context, organisationID string) (*Organisation, error) {
+	var org Organisation
+	if err := c.doJSON(ctx, http.MethodGet, "/org", url.Values{"organisation_id": {organisationID}}, nil, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// DeleteOrganisation deletes an organisation by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - organisationID: string. The organisation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteOrganisation(ctx context.Context, organisationID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/org", url.Values{"organisation_id": {organisationID}}, nil, nil)
+}
+
+// UpdateOrganisation updates an organisation's name, website, or email.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - organisationID: string. The organisation ID.
+//   - req: *UpdateOrganisationRequest. Fields to change.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateOrganisation(ctx context.Context, organisationID string, req *UpdateOrganisationRequest) error {
+	return c.doJSON(ctx, http.MethodPatch, "/org", url.Values{"organisation_id": {organisationID}}, req, nil)
+}
+
+// ListOrganisations lists the user's organisations.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []Organisation. The organisations.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListOrganisations(ctx context.Context) ([]Organisation, error) {
+	var orgs []Organisation
+	if err := c.doJSON(ctx, http.MethodGet, "/org/list", nil, nil, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// AddOrganisationOwner makes a user an owner of the organisation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *AddOrganisationOwnerRequest. Organisation ID and user email.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) AddOrganisationOwner(ctx context.Context, req *AddOrganisationOwnerRequest) error {
+	return c.doJSON(ctx, http.MethodPost, "/org/owner", nil, req, nil)
+}
+
+// RemoveOrganisationOwner removes the caller as an owner of the organisation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - organisationID: string. The organisation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RemoveOrganisationOwner(ctx context.Context, organisationID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/org/owner", url.Values{"organisation_id": {organisationID}}, nil, nil)
+}
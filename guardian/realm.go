@@ -0,0 +1,98 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateRealm creates a realm.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *CreateRealmRequest. Name, subnet, visibility, veil, and optional subscription.
+//
+// Outputs:
+//   - *Realm. The created realm.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateRealm(ctx context.Context, req *CreateRealmRequest) (*Realm, error) {
+	var realm Realm
+	if err := c.doJSON(ctx, http.MethodPost, "/realm", nil, req, &realm); err != nil {
+		return nil, err
+	}
+	return &realm, nil
+}
+
+// GetRealm returns a realm by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - realmID: string. The realm ID.
+//
+// Outputs:
+//   - *Realm. The realm.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetRealm(ctx context.Context, realmID string) (*Realm, error) {
+	var realm Realm
+	if err := c.doJSON(ctx, http.MethodGet, "/realm", url.Values{"realm_id": {realmID}}, nil, &realm); err != nil {
+		return nil, err
+	}
+	return &realm, nil
+}
+
+// DeleteRealm deletes a realm by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - realmID: string. The realm ID.
+//
+// Outputs:
+//   - *Realm. The deleted realm.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteRealm(ctx context.Context, realmID string) (*Realm, error) {
+	var realm Realm
+	if err := c.doJSON(ctx, http.MethodDelete, "/realm", url.Values{"realm_id": {realmID}}, nil, &realm); err != nil {
+		return nil, err
+	}
+	return &realm, nil
+}
+
+// ListRealms lists the realms visible to the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []Realm. The realms.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListRealms(ctx context.Context) ([]Realm, error) {
+	var realms []Realm
+	if err := c.doJSON(ctx, http.MethodGet, "/realm/list", nil, nil, &realms); err != nil {
+		return nil, err
+	}
+	return realms, nil
+}
+
+// UpdateRealmSubscription attaches a subscription to a realm.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *UpdateRealmSubscriptionRequest. Realm and subscription IDs.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateRealmSubscription(ctx context.Context, req *UpdateRealmSubscriptionRequest) error {
+	return c.doJSON(ctx, http.MethodPatch, "/realm/subscription", nil, req, nil)
+}
+
+// DeleteRealmSubscription detaches the subscription from a realm.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - realmID: string. The realm ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteRealmSubscription(ctx context.Context, realmID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/realm/subscription", url.Values{"realm_id": {realmID}}, nil, nil)
+}
@@ -0,0 +1,130 @@
+package guardian
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// SubscribeConflux starts a conflux subscription checkout.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *SubscribeRequest. Service tier and redirect URLs.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body (checkout session).
+//   - err: error. Non-nil if the request fails.
+func (c *Client) SubscribeConflux(ctx context.Context, req *SubscribeRequest) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodPost, "/stripe/subscribe/conflux", nil, req, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// SubscribeRealm starts a realm subscription checkout.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *RealmSubscribeRequest. Redirect URLs.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body (checkout session).
+//   - err: error. Non-nil if the request fails.
+func (c *Client) SubscribeRealm(ctx context.Context, req *RealmSubscribeRequest) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodPost, "/stripe/subscribe/realm", nil, req, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// ListSubscriptions lists the user's subscriptions.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []UserSubscription. The subscriptions.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListSubscriptions(ctx context.Context) ([]UserSubscription, error) {
+	var subscriptions []UserSubscription
+	if err := c.doJSON(ctx, http.MethodGet, "/stripe/subscriptions", nil, nil, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// CancelSubscription cancels a subscription.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - subscriptionID: string. The subscription ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/stripe/subscription", url.Values{"subscription_id": {subscriptionID}}, nil, nil)
+}
+
+// ListConfluxSubscriptions lists the user's conflux subscriptions.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListConfluxSubscriptions(ctx context.Context) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/stripe/subscriptions/conflux", nil, nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// UpdateConfluxSubscription changes the service tier of a conflux subscription.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *UpdateSubscriptionRequest. Subscription ID and service tier.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateConfluxSubscription(ctx context.Context, req *UpdateSubscriptionRequest) error {
+	return c.doJSON(ctx, http.MethodPost, "/stripe/subscription/conflux/update", nil, req, nil)
+}
+
+// ListRealmSubscriptions lists the user's realm subscriptions.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []UserSubscription. The subscriptions.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListRealmSubscriptions(ctx context.Context) ([]UserSubscription, error) {
+	var subscriptions []UserSubscription
+	if err := c.doJSON(ctx, http.MethodGet, "/stripe/subscriptions/realm", nil, nil, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetServiceTier returns the user's service tier.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - json.RawMessage. The raw response body.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetServiceTier(ctx context.Context) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, "/stripe/service-tier", nil, nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
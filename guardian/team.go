@@ -0,0 +1,210 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateTeam creates a team in an organisation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - organisationID: string. The organisation ID.
+//   - req: *CreateTeamRequest. Name and optional email/realm.
+//
+// Outputs:
+//   - *Team. The created team.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateTeam(ctx context.Context, organisationID string, req *CreateTeamRequest) (*Team, error) {
+	var team Team
+	if err := c.doJSON(ctx, http.MethodPost, "/org/team", url.Values{"organisation_id": {organisationID}}, req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// GetTeam returns a team by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - teamID: string. The team ID.
+//
+// Outputs:
+//   - *Team. The team.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetTeam(ctx context.Context, teamID string) (*Team, error) {
+	var team Team
+	if err := c.doJSON(ctx, http.MethodGet, "/org/team", url.Values{"team_id": {teamID}}, nil, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// DeleteTeam deletes a team by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - teamID: string. The team ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteTeam(ctx context.Context, teamID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/org/team", url.Values{"team_id": {teamID}}, nil, nil)
+}
+
+// UpdateTeam updates a team's name or email.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - teamID: string. The team ID.
+//   - req: *UpdateTeamRequest. Fields to change.
+//
+// Outputs:
+//   - *Team. The updated team.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateTeam(ctx context.Context, teamID string, req *UpdateTeamRequest) (*Team, error) {
+	var team Team
+	if err := c.doJSON(ctx, http.MethodPatch, "/org/team", url.Values{"team_id": {teamID}}, req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// ListTeams lists the teams visible to the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []Team. The teams.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListTeams(ctx context.Context) ([]Team, error) {
+	var teams []Team
+	if err := c.doJSON(ctx, http.MethodGet, "/org/team/list", nil, nil, &teams); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// InviteTeamMember invites a user to a team by email.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *InviteTeamMemberRequest. Team ID and email.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) InviteTeamMember(ctx context.Context, req *InviteTeamMemberRequest) error {
+	return c.doJSON(ctx, http.MethodPost, "/org/team/invite", nil, req, nil)
+}
+
+// DeleteTeamInvitation withdraws a team invitation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - invitationID: string. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteTeamInvitation(ctx context.Context, invitationID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/org/team/invite", url.Values{"invitation_id": {invitationID}}, nil, nil)
+}
+
+// ListSentInvitations lists invitations sent by the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []TeamInvitation. The invitations.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListSentInvitations(ctx context.Context) ([]TeamInvitation, error) {
+	var invitations []TeamInvitation
+	if err := c.doJSON(ctx, http.MethodGet, "/org/team/invite/sent", nil, nil, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// ListReceivedInvitations lists invitations received by the user.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []TeamInvitation. The invitations.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListReceivedInvitations(ctx context.Context) ([]TeamInvitation, error) {
+	var invitations []TeamInvitation
+	if err := c.doJSON(ctx, http.MethodGet, "/org/team/invite/received", nil, nil, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptTeamInvitation accepts a team invitation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - invitationID: string. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) AcceptTeamInvitation(ctx context.Context, invitationID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/org/team/invite/accept", url.Values{"invitation_id": {invitationID}}, nil, nil)
+}
+
+// RejectTeamInvitation rejects a team invitation.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - invitationID: string. The invitation ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RejectTeamInvitation(ctx context.Context, invitationID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/org/team/invite/reject", url.Values{"invitation_id": {invitationID}}, nil, nil)
+}
+
+// UpdateTeamRealm binds a team to a realm, or unbinds it when RealmID is nil.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *UpdateTeamRealmRequest. Team ID and optional realm ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) UpdateTeamRealm(ctx context.Context, req *UpdateTeamRealmRequest) error {
+	return c.doJSON(ctx, http.MethodPatch, "/org/team/realm", nil, req, nil)
+}
+
+// ListTeamMembers lists the members of a team.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - teamID: string. The team ID.
+//
+// Outputs:
+//   - []TeamMember. The members.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListTeamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	var members []TeamMember
+	if err := c.doJSON(ctx, http.MethodGet, "/org/team/member", url.Values{"team_id": {teamID}}, nil, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// RemoveTeamMember removes a user from a team.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - teamID: string. The team ID.
+//   - memberUserID: string. The member's user ID.
+//
+// Outputs:
+//   - err: error. Non-nil if the request fails.
+func (c *Client) RemoveTeamMember(ctx context.Context, teamID string, memberUserID string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/org/team/member", url.Values{"team_id": {teamID}, "member_user_id": {memberUserID}}, nil, nil)
+}
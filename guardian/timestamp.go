@@ -0,0 +1,66 @@
+package guardian
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// timestampLayouts are the formats Timestamp accepts; layouts without a zone are read as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Timestamp is a time Guardian declares as a plain string rather than a date-time.
+// It accepts RFC 3339 and the common ISO 8601 variants; a value it cannot parse decodes as the zero time with Raw
+// set, so one unexpected format does not fail the decode of a whole list.
+type Timestamp struct {
+	time.Time
+	// Raw is the string Guardian sent.
+	Raw string
+}
+
+// UnmarshalJSON decodes a string or null; it only fails for other JSON types.
+//
+// Inputs:
+//   - data: []byte. The JSON value.
+//
+// Outputs:
+//   - err: error. Non-nil if the value is neither a string nor null.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = Timestamp{}
+	if raw == nil {
+		return nil
+	}
+	t.Raw = *raw
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, *raw); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the string Guardian sent, or the time in RFC 3339 for a Timestamp built in code.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - []byte. The JSON string.
+//   - err: error. Always nil.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.Raw != "" {
+		return json.Marshal(t.Raw)
+	}
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
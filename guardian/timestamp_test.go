@@ -0,0 +1,80 @@
+package guardian
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want time.Time
+		raw  string
+	}{
+		{"rfc3339", `"2026-05-01T12:30:00Z"`, time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC), "2026-05-01T12:30:00Z"},
+		{"offset", `"2026-05-01T14:30:00+02:00"`, time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC), "2026-05-01T14:30:00+02:00"},
+		{"no zone", `"2026-05-01T12:30:00.123456"`, time.Date(2026, 5, 1, 12, 30, 0, 123456000, time.UTC), "2026-05-01T12:30:00.123456"},
+		{"space separator", `"2026-05-01 12:30:00"`, time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC), "2026-05-01 12:30:00"},
+		{"unreadable", `"next tuesday"`, time.Time{}, "next tuesday"},
+		{"null", `null`, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			if err := json.Unmarshal([]byte(tt.json), &ts); err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+			}
+			if !ts.Time.Equal(tt.want) {
+				t.Errorf("Unmarshal(%s) time = %v, want %v", tt.json, ts.Time, tt.want)
+			}
+			if ts.Raw != tt.raw {
+				t.Errorf("Unmarshal(%s) raw = %q, want %q", tt.json, ts.Raw, tt.raw)
+			}
+		})
+	}
+}
+
+func TestTimestampUnmarshalRejectsNonString(t *testing.T) {
+	var ts Timestamp
+	if err := json.Unmarshal([]byte(`12345`), &ts); err == nil {
+		t.Fatal("Unmarshal(12345) error = nil, want an error")
+	}
+}
+
+func TestRegistrationTokenListToleratesUnreadableTime(t *testing.T) {
+	body := `[
+		{"token_id": "a", "created_at": "2026-05-01T12:30:00Z", "expires_at": "2026-06-01T12:30:00Z"},
+		{"token_id": "b", "created_at": "yesterday", "expires_at": "2026-06-01 12:30:00"}
+	]`
+	var tokens []RegistrationTokenInfo
+	if err := json.Unmarshal([]byte(body), &tokens); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("got %d tokens, want 2", len(tokens))
+	}
+	if !tokens[1].CreatedAt.IsZero() || tokens[1].CreatedAt.Raw != "yesterday" {
+		t.Errorf("tokens[1].CreatedAt = %+v, want the zero time with raw %q", tokens[1].CreatedAt, "yesterday")
+	}
+	if !tokens[1].ExpiresAt.Equal(tokens[0].ExpiresAt.Time) {
+		t.Errorf("tokens[1].ExpiresAt = %v, want %v", tokens[1].ExpiresAt.Time, tokens[0].ExpiresAt.Time)
+	}
+}
+
+func TestTimestampMarshalKeepsRaw(t *testing.T) {
+	for _, raw := range []string{`"2026-05-01 12:30:00"`, `"next tuesday"`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(raw), &ts); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", raw, err)
+		}
+		got, err := json.Marshal(ts)
+		if err != nil {
+			t.Fatalf("Marshal error = %v", err)
+		}
+		if string(got) != raw {
+			t.Errorf("Marshal = %s, want %s", got, raw)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package guardian
+
+import "time"
+
+// LoginResponse is the token returned by POST /auth/login.
+type LoginResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+// UserProfile is the authenticated user's profile.
+type UserProfile struct {
+	ID          string     `json:"id"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	Email       string     `json:"email"`
+	IsSuperuser bool       `json:"is_superuser"`
+	MP          int        `json:"mp"`
+	DisplayName string     `json:"display_name,omitempty"`
+}
+
+// UpdateDisplayNameRequest is the body of PATCH /auth/profile/display-name.
+type UpdateDisplayNameRequest struct {
+	DisplayName string `json:"display_name"`
+}
+
+// CreateRegistrationTokenRequest is the body of POST /auth/create/registration-token.
+type CreateRegistrationTokenRequest struct {
+	RealmID string `json:"realm_id"`
+	// ExpiresAfter is the token lifetime in seconds.
+	ExpiresAfter int    `json:"expires_after"`
+	Tag          string `json:"tag,omitempty"`
+}
+
+// RegistrationToken is a newly created registration token; Token is only returned once.
+type RegistrationToken struct {
+	TokenID string `json:"token_id"`
+	Token   string `json:"token,omitempty"`
+}
+
+// RegistrationTokenInfo describes an existing registration token; the spec declares its times as plain strings.
+type RegistrationTokenInfo struct {
+	TokenID   string    `json:"token_id"`
+	CreatedAt Timestamp `json:"created_at"`
+	UserID    string    `json:"user_id"`
+	RealmID   string    `json:"realm_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt Timestamp `json:"expires_at"`
+	Tag       string    `json:"tag,omitempty"`
+}
+
+// CreateRealmRequest is the body of POST /realm.
+type CreateRealmRequest struct {
+	Name           string `json:"name"`
+	Subnet         string `json:"subnet"`
+	Public         bool   `json:"public"`
+	VeilID         string `json:"veil_id"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+}
+
+// Realm is a logical VeilNet network.
+type Realm struct {
+	ID        string     `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UserID    string     `json:"user_id"`
+	VeilID    string     `json:"veil_id"`
+	Name      string     `json:"name"`
+	Subnet    string     `json:"subnet"`
+	Public    bool       `json:"public"`
+	Region    string     `json:"region,omitempty"`
+	VeilHost  string     `json:"veil_host,omitempty"`
+	VeilPort  int        `json:"veil_port,omitempty"`
+	Portals   int        `json:"portals"`
+	Status    string     `json:"status,omitempty"`
+}
+
+// UpdateRealmSubscriptionRequest is the body of PATCH /realm/subscription.
+type UpdateRealmSubscriptionRequest struct {
+	RealmID        string `json:"realm_id"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+// CreateConfluxRequest is the body of POST /conflux.
+type CreateConfluxRequest struct {
+	RealmID string `json:"realm_id"`
+	Tag     string `json:"tag,omitempty"`
+}
+
+// RegisterConfluxRequest is the body of POST /conflux/register (authenticated with a registration token).
+type RegisterConfluxRequest struct {
+	Tag      string `json:"tag,omitempty"`
+	CIDR     string `json:"cidr,omitempty"`
+	Teams    string `json:"teams,omitempty"`
+	JWT      string `json:"jwt,omitempty"`
+	JWKSURL  string `json:"jwks_url,omitempty"`
+	Audience string `json:"audience,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+}
+
+// RegisterConfluxResponse is the conflux identity returned by POST /conflux/register.
+type RegisterConfluxResponse struct {
+	ConfluxID string `json:"conflux_id"`
+	Token     string `json:"token"`
+}
+
+// Conflux is a conflux node as known to Guardian.
+type Conflux struct {
+	ID        string     `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	UserID    string     `json:"user_id"`
+	Tag       string     `json:"tag,omitempty"`
+	Signature string     `json:"signature,omitempty"`
+	IPLeaseID string     `json:"ip_lease_id,omitempty"`
+	CIDR      string     `json:"cidr,omitempty"`
+	Subnet    string     `json:"subnet"`
+	Realm     string     `json:"plane"`
+	RealmID   string     `json:"plane_id"`
+	Portal    bool       `json:"portal"`
+	Public    bool       `json:"public"`
+	CAPEM     string     `json:"ca_pem"`
+	KeyPEM    string     `json:"key_pem"`
+	CertPEM   string     `json:"cert_pem"`
+	VeilHost  string     `json:"veil_host"`
+	VeilPort  int        `json:"veil_port"`
+	Region    string     `json:"region"`
+}
+
+// ConfluxSessionLoginRequest is the body of POST /conflux/session/login; Signature is the anchor's cryptographic
+// signature, which only the anchor holds.
+type ConfluxSessionLoginRequest struct {
+	Signature string `json:"signature"`
+	Portal    bool   `json:"portal"`
+}
+
+// AddConfluxTeamRequest is the body of POST /conflux/team.
+type AddConfluxTeamRequest struct {
+	ConfluxID string `json:"conflux_id"`
+	TeamID    string `json:"team_id"`
+}
+
+// RemoveConfluxTeamRequest is the body of DELETE /conflux/team.
+type RemoveConfluxTeamRequest struct {
+	ConfluxID string `json:"conflux_id"`
+	TeamID    string `json:"team_id"`
+}
+
+// CreateVeilRequest is the body of POST /veil/.
+type CreateVeilRequest struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Public bool   `json:"public"`
+}
+
+// Veil is a veil relay server.
+type Veil struct {
+	ID        string     `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Name      string     `json:"name"`
+	Region    string     `json:"region"`
+	Host      string     `json:"host"`
+	Port      int        `json:"port"`
+}
+
+// CreateOrganisationRequest is the body of POST /org.
+type CreateOrganisationRequest struct {
+	Name    string `json:"name"`
+	Website string `json:"website,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+// UpdateOrganisationRequest is the body of PATCH /org; empty fields are left unchanged.
+type UpdateOrganisationRequest struct {
+	Name    string `json:"name,omitempty"`
+	Website string `json:"website,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+// Organisation owns teams.
+type Organisation struct {
+	ID        string     `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Website   string     `json:"website,omitempty"`
+	Email     string     `json:"email,omitempty"`
+}
+
+// AddOrganisationOwnerRequest is the body of POST /org/owner.
+type AddOrganisationOwnerRequest struct {
+	OrganisationID string `json:"organisation_id"`
+	UserEmail      string `json:"user_email"`
+}
+
+// CreateTeamRequest is the body of POST /org/team.
+type CreateTeamRequest struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	RealmID string `json:"realm_id,omitempty"`
+}
+
+// UpdateTeamRequest is the body of PATCH /org/team; empty fields are left unchanged.
+type UpdateTeamRequest struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// Team is a group of users within an organisation, optionally bound to a realm.
+type Team struct {
+	ID             string     `json:"id"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UserID         string     `json:"user_id"`
+	OrganisationID string     `json:"organisation_id"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email,omitempty"`
+	RealmID        string     `json:"realm_id,omitempty"`
+}
+
+// InviteTeamMemberRequest is the body of POST /org/team/invite.
+type InviteTeamMemberRequest struct {
+	TeamID string `json:"team_id"`
+	Email  string `json:"email"`
+}
+
+// TeamInvitation is a pending or resolved team invitation.
+type TeamInvitation struct {
+	ID               string     `json:"id"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	UserEmail        string     `json:"user_email"`
+	OrganisationName string     `json:"organisation_name"`
+	TeamName         string     `json:"team_name"`
+	InvitedUserEmail string     `json:"invited_user_email"`
+	Status           string     `json:"status"`
+}
+
+// UpdateTeamRealmRequest is the body of PATCH /org/team/realm; a nil RealmID unbinds the team.
+type UpdateTeamRealmRequest struct {
+	TeamID  string  `json:"team_id"`
+	RealmID *string `json:"realm_id"`
+}
+
+// TeamMember is a member of a team.
+type TeamMember struct {
+	ID          string     `json:"id"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	TeamID      string     `json:"team_id"`
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email"`
+	DisplayName string     `json:"display_name,omitempty"`
+}
+
+// SubscribeRequest is the body of POST /stripe/subscribe/conflux.
+type SubscribeRequest struct {
+	ServiceTier int    `json:"service_tier"`
+	SuccessURL  string `json:"success_url"`
+	CancelURL   string `json:"cancel_url"`
+}
+
+// RealmSubscribeRequest is the body of POST /stripe/subscribe/realm.
+type RealmSubscribeRequest struct {
+	SuccessURL string `json:"success_url"`
+	CancelURL  string `json:"cancel_url"`
+}
+
+// UpdateSubscriptionRequest is the body of POST /stripe/subscription/conflux/update.
+type UpdateSubscriptionRequest struct {
+	SubscriptionID string `json:"subscription_id"`
+	ServiceTier    int    `json:"service_tier"`
+}
+
+// UserSubscriptionMetadata is the VeilNet metadata attached to a subscription.
+type UserSubscriptionMetadata struct {
+	UserID      string `json:"user_id"`
+	ServiceTier int    `json:"service_tier"`
+	// Type is "plane" (realm) or "conflux".
+	Type string `json:"type,omitempty"`
+}
+
+// UserSubscription is a Stripe subscription owned by the user.
+type UserSubscription struct {
+	ID                    string                   `json:"id"`
+	CancelAtPeriodEnd     bool                     `json:"cancel_at_period_end,omitempty"`
+	CurrentPeriodStart    *time.Time               `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd      *time.Time               `json:"current_period_end,omitempty"`
+	Metadata              UserSubscriptionMetadata `json:"metadata"`
+	Status                string                   `json:"status"`
+	ApplicationFeePercent float64                  `json:"application_fee_percent,omitempty"`
+	CancelAt              *time.Time               `json:"cancel_at,omitempty"`
+	CanceledAt            *time.Time               `json:"canceled_at,omitempty"`
+	Created               *time.Time               `json:"created,omitempty"`
+	EndedAt               *time.Time               `json:"ended_at,omitempty"`
+	Livemode              bool                     `json:"livemode"`
+	StartDate             *time.Time               `json:"start_date,omitempty"`
+	Customer              string                   `json:"customer"`
+	UpdatedAt             time.Time                `json:"updated_at"`
+}
@@ -0,0 +1,74 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateVeil registers a veil server.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - req: *CreateVeilRequest. Name, region, host, port, visibility.
+//
+// Outputs:
+//   - *Veil. The created veil.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) CreateVeil(ctx context.Context, req *CreateVeilRequest) (*Veil, error) {
+	var veil Veil
+	if err := c.doJSON(ctx, http.MethodPost, "/veil/", nil, req, &veil); err != nil {
+		return nil, err
+	}
+	return &veil, nil
+}
+
+// GetVeil returns a veil by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - veilID: string. The veil ID.
+//
+// Outputs:
+//   - *Veil. The veil.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) GetVeil(ctx context.Context, veilID string) (*Veil, error) {
+	var veil Veil
+	if err := c.doJSON(ctx, http.MethodGet, "/veil/", url.Values{"veil_id": {veilID}}, nil, &veil); err != nil {
+		return nil, err
+	}
+	return &veil, nil
+}
+
+// DeleteVeil deletes a veil by ID.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - veilID: string. The veil ID.
+//
+// Outputs:
+//   - *Veil. The deleted veil.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) DeleteVeil(ctx context.Context, veilID string) (*Veil, error) {
+	var veil Veil
+	if err := c.doJSON(ctx, http.MethodDelete, "/veil/", url.Values{"veil_id": {veilID}}, nil, &veil); err != nil {
+		return nil, err
+	}
+	return &veil, nil
+}
+
+// ListVeils lists the available veils.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - []Veil. The veils.
+//   - err: error. Non-nil if the request fails.
+func (c *Client) ListVeils(ctx context.Context) ([]Veil, error) {
+	var veils []Veil
+	if err := c.doJSON(ctx, http.MethodGet, "/veil/list", nil, nil, &veils); err != nil {
+		return nil, err
+	}
+	return veils, nil
+}
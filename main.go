@@ -2,9 +2,11 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/alecthomas/kong"
+	"github.com/veil-net/conflux/anchor"
 	"github.com/veil-net/conflux/cli"
 )
 
@@ -15,13 +17,20 @@ var version = "Beta-v1.0.3"
 //
 // Inputs: none.
 //
-// Outputs: none. Exits with code 0 on success or 1 if the selected command returns an error.
+// Outputs: none. Exits with code 0 on success, the error's own code if it has an ExitCode method, or 1 otherwise.
 func main() {
-	// Parse the CLI arguments
+	// Parse the CLI arguments, --config files are read by the CLI's loader
+	configuration := kong.Configuration(cli.ConfigLoader)
 	var cli cli.CLI
-	ctx := kong.Parse(&cli, kong.Vars{"version": version})
+	ctx := kong.Parse(&cli, kong.Vars{"version": version}, configuration)
+	anchor.SetConfigDir(cli.ConfigDir)
+	ctx.FatalIfErrorf(anchor.SetInstance(cli.Instance))
 	err := ctx.Run()
 	if err != nil {
+		var coder interface{ ExitCode() int }
+		if errors.As(err, &coder) {
+			os.Exit(coder.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,296 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// CreatedToken is a registration token created by Apply; the secret is only available here.
+type CreatedToken struct {
+	Realm   string `json:"realm" yaml:"realm"`
+	Tag     string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	TokenID string `json:"token_id" yaml:"token_id"`
+	Token   string `json:"token" yaml:"token"`
+}
+
+// Result reports what Apply did.
+type Result struct {
+	Applied []Change       `json:"applied" yaml:"applied"`
+	Tokens  []CreatedToken `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+}
+
+// applier carries IDs resolved while a plan is applied, so later changes can reference resources created earlier.
+type applier struct {
+	client   *guardian.Client
+	realmIDs map[string]string
+	teamIDs  map[string]string
+	tokens   []CreatedToken
+}
+
+// realmID resolves a manifest realm name to its Guardian ID.
+func (a *applier) realmID(name string) (string, error) {
+	id, ok := a.realmIDs[name]
+	if !ok {
+		return "", fmt.Errorf("realm %q does not exist", name)
+	}
+	return id, nil
+}
+
+// teamID resolves a manifest team key to its Guardian ID.
+func (a *applier) teamID(key string) (string, error) {
+	id, ok := a.teamIDs[key]
+	if !ok {
+		return "", fmt.Errorf("team %q does not exist", key)
+	}
+	return id, nil
+}
+
+// Apply executes the plan in order, stopping at the first failure.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: *guardian.Client. Client carrying the user session.
+//   - onApplied: func(Change). Optional callback after each successful change.
+//
+// Outputs:
+//   - *Result. The changes applied and tokens created, also on failure.
+//   - err: error. Non-nil if the plan has conflicts or a change fails.
+func (p *Plan) Apply(ctx context.Context, client *guardian.Client, onApplied func(Change)) (*Result, error) {
+	result := &Result{}
+	if conflicts := p.Count(ActionConflict); conflicts > 0 {
+		return result, fmt.Errorf("plan has %d conflict(s) that cannot be applied", conflicts)
+	}
+
+	a := &applier{
+		client:   client,
+		realmIDs: make(map[string]string, len(p.realmIDs)),
+		teamIDs:  make(map[string]string, len(p.teamIDs)),
+	}
+	for name, id := range p.realmIDs {
+		a.realmIDs[name] = id
+	}
+	for key, id := range p.teamIDs {
+		a.teamIDs[key] = id
+	}
+
+	for _, change := range p.Changes {
+		err := change.apply(ctx, a)
+		result.Tokens = a.tokens
+		if err != nil {
+			return result, fmt.Errorf("failed to %s %s %s: %w", change.Action, change.Kind, change.Name, err)
+		}
+		result.Applied = append(result.Applied, change)
+		if onApplied != nil {
+			onApplied(change)
+		}
+	}
+	return result, nil
+}
+
+// createRealm plans the creation of a realm.
+func createRealm(realm Realm, veil *guardian.Veil) Change {
+	return Change{
+		Action: ActionCreate,
+		Kind:   "realm",
+		Name:   realm.Name,
+		Detail: fmt.Sprintf("subnet %s, veil %s, public %t", realm.Subnet, veil.Name, realm.Public),
+		apply: func(ctx context.Context, a *applier) error {
+			created, err := a.client.CreateRealm(ctx, &guardian.CreateRealmRequest{
+				Name:           realm.Name,
+				Subnet:         realm.Subnet,
+				Public:         realm.Public,
+				VeilID:         veil.ID,
+				SubscriptionID: realm.SubscriptionID,
+			})
+			if err != nil {
+				return err
+			}
+			a.realmIDs[realm.Name] = created.ID
+			return nil
+		},
+	}
+}
+
+// deleteRealm plans the deletion of an undeclared realm.
+func deleteRealm(realm guardian.Realm) Change {
+	return Change{
+		Action: ActionDelete,
+		Kind:   "realm",
+		Name:   realm.Name,
+		Detail: realm.ID,
+		apply: func(ctx context.Context, a *applier) error {
+			_, err := a.client.DeleteRealm(ctx, realm.ID)
+			return err
+		},
+	}
+}
+
+// createTeam plans the creation of a team.
+func createTeam(team Team, org *guardian.Organisation) Change {
+	return Change{
+		Action: ActionCreate,
+		Kind:   "team",
+		Name:   team.Key(),
+		Detail: fmt.Sprintf("organisation %s", org.ID),
+		apply: func(ctx context.Context, a *applier) error {
+			created, err := a.client.CreateTeam(ctx, org.ID, &guardian.CreateTeamRequest{
+				Name:  team.Name,
+				Email: team.Email,
+			})
+			if err != nil {
+				return err
+			}
+			a.teamIDs[team.Key()] = created.ID
+			return nil
+		},
+	}
+}
+
+// updateTeam plans an in-place update of a team's email.
+func updateTeam(team Team, current *guardian.Team) Change {
+	return Change{
+		Action: ActionUpdate,
+		Kind:   "team",
+		Name:   team.Key(),
+		Detail: fmt.Sprintf("email %q -> %q", current.Email, team.Email),
+		apply: func(ctx context.Context, a *applier) error {
+			_, err := a.client.UpdateTeam(ctx, current.ID, &guardian.UpdateTeamRequest{Email: team.Email})
+			return err
+		},
+	}
+}
+
+// deleteTeam plans the deletion of an undeclared team.
+func deleteTeam(team guardian.Team) Change {
+	return Change{
+		Action: ActionDelete,
+		Kind:   "team",
+		Name:   team.OrganisationID + "/" + team.Name,
+		Detail: team.ID,
+		apply: func(ctx context.Context, a *applier) error {
+			return a.client.DeleteTeam(ctx, team.ID)
+		},
+	}
+}
+
+// bindTeam plans binding a team to its declared realm, or unbinding it.
+func bindTeam(team Team) Change {
+	change := Change{
+		Action: ActionUpdate,
+		Kind:   "binding",
+		Name:   team.Key(),
+		Detail: "bind to realm " + *team.Realm,
+	}
+	if *team.Realm == "" {
+		change.Detail = "unbind from realm"
+	}
+	change.apply = func(ctx context.Context, a *applier) error {
+		teamID, err := a.teamID(team.Key())
+		if err != nil {
+			return err
+		}
+		req := &guardian.UpdateTeamRealmRequest{TeamID: teamID}
+		if *team.Realm != "" {
+			realmID, err := a.realmID(*team.Realm)
+			if err != nil {
+				return err
+			}
+			req.RealmID = &realmID
+		}
+		return a.client.UpdateTeamRealm(ctx, req)
+	}
+	return change
+}
+
+// addMember plans adding a conflux to a team; ensures tolerate an existing membership.
+func addMember(team Team, conflux *guardian.Conflux, action Action) Change {
+	detail := "add conflux " + conflux.ID
+	if action == ActionEnsure {
+		detail += " (live membership unknown)"
+	}
+	return Change{
+		Action: action,
+		Kind:   "membership",
+		Name:   team.Key(),
+		Detail: detail,
+		apply: func(ctx context.Context, a *applier) error {
+			teamID, err := a.teamID(team.Key())
+			if err != nil {
+				return err
+			}
+			err = a.client.AddConfluxTeam(ctx, &guardian.AddConfluxTeamRequest{ConfluxID: conflux.ID, TeamID: teamID})
+			if action == ActionEnsure && guardian.StatusCode(err) == http.StatusConflict {
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// removeMember plans removing a conflux from a team.
+func removeMember(team Team, confluxID string) Change {
+	return Change{
+		Action: ActionDelete,
+		Kind:   "membership",
+		Name:   team.Key(),
+		Detail: "remove conflux " + confluxID,
+		apply: func(ctx context.Context, a *applier) error {
+			teamID, err := a.teamID(team.Key())
+			if err != nil {
+				return err
+			}
+			return a.client.RemoveConfluxTeam(ctx, &guardian.RemoveConfluxTeamRequest{ConfluxID: confluxID, TeamID: teamID})
+		},
+	}
+}
+
+// createToken plans the creation of a registration token.
+func createToken(token Token, reason string) Change {
+	expires := token.Expires
+	if expires == 0 {
+		expires = DefaultTokenExpiry
+	}
+	return Change{
+		Action: ActionCreate,
+		Kind:   "token",
+		Name:   token.Key(),
+		Detail: fmt.Sprintf("expires after %s, %s", expires, reason),
+		apply: func(ctx context.Context, a *applier) error {
+			realmID, err := a.realmID(token.Realm)
+			if err != nil {
+				return err
+			}
+			created, err := a.client.CreateRegistrationToken(ctx, &guardian.CreateRegistrationTokenRequest{
+				RealmID:      realmID,
+				ExpiresAfter: int(expires.Seconds()),
+				Tag:          token.Tag,
+			})
+			if err != nil {
+				return err
+			}
+			a.tokens = append(a.tokens, CreatedToken{
+				Realm:   token.Realm,
+				Tag:     token.Tag,
+				TokenID: created.TokenID,
+				Token:   created.Token,
+			})
+			return nil
+		},
+	}
+}
+
+// deleteToken plans revoking an undeclared registration token.
+func deleteToken(realm string, info guardian.RegistrationTokenInfo) Change {
+	return Change{
+		Action: ActionDelete,
+		Kind:   "token",
+		Name:   realm + "/" + info.Tag,
+		Detail: info.TokenID,
+		apply: func(ctx context.Context, a *applier) error {
+			return a.client.RevokeRegistrationToken(ctx, info.TokenID)
+		},
+	}
+}
@@ -0,0 +1,162 @@
+// Package manifest loads declarative fleet manifests and reconciles them against Guardian.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTokenExpiry is the lifetime of tokens created without an explicit expiry.
+const DefaultTokenExpiry = 24 * time.Hour
+
+// Manifest is the desired state of realms, teams, and registration tokens.
+type Manifest struct {
+	// Prune deletes live realms, teams, and tokens that the manifest does not declare.
+	Prune  bool    `yaml:"prune,omitempty" json:"prune,omitempty"`
+	Realms []Realm `yaml:"realms,omitempty" json:"realms,omitempty"`
+	Teams  []Team  `yaml:"teams,omitempty" json:"teams,omitempty"`
+	Tokens []Token `yaml:"tokens,omitempty" json:"tokens,omitempty"`
+}
+
+// Realm is a desired realm, identified by name.
+type Realm struct {
+	Name   string `yaml:"name" json:"name"`
+	Subnet string `yaml:"subnet" json:"subnet"`
+	// Veil is the veil name or ID the realm runs on.
+	Veil   string `yaml:"veil" json:"veil"`
+	Public bool   `yaml:"public,omitempty" json:"public,omitempty"`
+	// SubscriptionID is only used when the realm is created.
+	SubscriptionID string `yaml:"subscription_id,omitempty" json:"subscription_id,omitempty"`
+}
+
+// Team is a desired team, identified by organisation and name.
+type Team struct {
+	Name string `yaml:"name" json:"name"`
+	// Organisation is the organisation name or ID.
+	Organisation string `yaml:"organisation" json:"organisation"`
+	Email        string `yaml:"email,omitempty" json:"email,omitempty"`
+	// Realm is the realm name the team is bound to; nil leaves the binding alone, "" unbinds.
+	Realm *string `yaml:"realm,omitempty" json:"realm,omitempty"`
+	// Confluxes are the conflux tags or IDs that belong to the team; nil leaves membership alone.
+	Confluxes []string `yaml:"confluxes,omitempty" json:"confluxes,omitempty"`
+}
+
+// Token is a desired registration token, identified by realm and tag.
+type Token struct {
+	// Realm is the realm name the token registers confluxes into.
+	Realm   string        `yaml:"realm" json:"realm"`
+	Tag     string        `yaml:"tag,omitempty" json:"tag,omitempty"`
+	Expires time.Duration `yaml:"expires,omitempty" json:"expires,omitempty"`
+	// RenewBefore creates a replacement when the newest matching token expires within this window.
+	RenewBefore time.Duration `yaml:"renew_before,omitempty" json:"renew_before,omitempty"`
+}
+
+// Key returns the identity of the team within the manifest.
+func (t *Team) Key() string {
+	return t.Organisation + "/" + t.Name
+}
+
+// Key returns the identity of the token within the manifest.
+func (t *Token) Key() string {
+	return t.Realm + "/" + t.Tag
+}
+
+// Load reads and validates a manifest; YAML and JSON are both accepted.
+//
+// Inputs:
+//   - path: string. The manifest file.
+//
+// Outputs:
+//   - *Manifest. The parsed manifest.
+//   - err: error. Non-nil if the file cannot be read, has unknown fields, or is invalid.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Validate checks required fields, duplicates, and references between entries.
+//
+// Inputs:
+//   - m: *Manifest. The manifest.
+//
+// Outputs:
+//   - err: error. All problems found, joined; nil if the manifest is valid.
+func (m *Manifest) Validate() error {
+	var errs []error
+
+	realms := make(map[string]bool, len(m.Realms))
+	for i, realm := range m.Realms {
+		switch {
+		case realm.Name == "":
+			errs = append(errs, fmt.Errorf("realms[%d]: name is required", i))
+			continue
+		case realms[realm.Name]:
+			errs = append(errs, fmt.Errorf("realms[%d]: duplicate realm %q", i, realm.Name))
+		}
+		realms[realm.Name] = true
+		if _, _, err := net.ParseCIDR(realm.Subnet); err != nil {
+			errs = append(errs, fmt.Errorf("realm %q: subnet %q is not a CIDR", realm.Name, realm.Subnet))
+		}
+		if realm.Veil == "" {
+			errs = append(errs, fmt.Errorf("realm %q: veil is required", realm.Name))
+		}
+	}
+
+	teams := make(map[string]bool, len(m.Teams))
+	for i, team := range m.Teams {
+		if team.Name == "" || team.Organisation == "" {
+			errs = append(errs, fmt.Errorf("teams[%d]: name and organisation are required", i))
+			continue
+		}
+		if teams[team.Key()] {
+			errs = append(errs, fmt.Errorf("teams[%d]: duplicate team %q", i, team.Key()))
+		}
+		teams[team.Key()] = true
+		if team.Realm != nil && *team.Realm != "" && !realms[*team.Realm] {
+			errs = append(errs, fmt.Errorf("team %q: realm %q is not declared in the manifest", team.Key(), *team.Realm))
+		}
+	}
+
+	tokens := make(map[string]bool, len(m.Tokens))
+	for i, token := range m.Tokens {
+		switch {
+		case token.Realm == "":
+			errs = append(errs, fmt.Errorf("tokens[%d]: realm is required", i))
+			continue
+		case !realms[token.Realm]:
+			errs = append(errs, fmt.Errorf("tokens[%d]: realm %q is not declared in the manifest", i, token.Realm))
+		case tokens[token.Key()]:
+			errs = append(errs, fmt.Errorf("tokens[%d]: duplicate token %q", i, token.Key()))
+		}
+		tokens[token.Key()] = true
+		if token.Expires < 0 || token.RenewBefore < 0 {
+			errs = append(errs, fmt.Errorf("token %q: expires and renew_before must not be negative", token.Key()))
+		}
+		if token.Expires != 0 && token.RenewBefore >= token.Expires {
+			errs = append(errs, fmt.Errorf("token %q: renew_before must be shorter than expires", token.Key()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,513 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// Action is what a change does to converge live state.
+type Action string
+
+const (
+	// ActionCreate creates a missing resource.
+	ActionCreate Action = "create"
+	// ActionUpdate changes a resource in place.
+	ActionUpdate Action = "update"
+	// ActionDelete removes a resource.
+	ActionDelete Action = "delete"
+	// ActionEnsure re-applies a change whose live state Guardian cannot report; it is not counted as drift.
+	ActionEnsure Action = "ensure"
+	// ActionConflict is drift that cannot be converged in place; apply refuses to run while any exist.
+	ActionConflict Action = "conflict"
+)
+
+// Change is a single planned operation.
+type Change struct {
+	Action Action `json:"action" yaml:"action"`
+	// Kind is realm, team, binding, membership, or token.
+	Kind   string `json:"kind" yaml:"kind"`
+	Name   string `json:"name" yaml:"name"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+
+	apply func(ctx context.Context, a *applier) error
+}
+
+// Plan is the ordered list of changes that converges live state to a manifest.
+type Plan struct {
+	Changes []Change `json:"changes" yaml:"changes"`
+
+	realmIDs map[string]string
+	teamIDs  map[string]string
+}
+
+// Options tunes planning.
+type Options struct {
+	// Prune deletes undeclared resources, in addition to the manifest's own prune setting.
+	Prune bool
+	// ConfluxTokens maps conflux IDs to their conflux tokens, used to read live team membership.
+	// Membership of other confluxes cannot be read, so it is only ensured and never removed.
+	ConfluxTokens map[string]string
+}
+
+// Drift returns the number of changes that represent a difference from live state.
+func (p *Plan) Drift() int {
+	drift := 0
+	for _, change := range p.Changes {
+		if change.Action != ActionEnsure {
+			drift++
+		}
+	}
+	return drift
+}
+
+// Count returns the number of changes with the given action.
+func (p *Plan) Count(action Action) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// live is the Guardian state a plan is computed from.
+type live struct {
+	profile   *guardian.UserProfile
+	realms    []guardian.Realm
+	veils     []guardian.Veil
+	orgs      []guardian.Organisation
+	teams     []guardian.Team
+	confluxes []guardian.Conflux
+	tokens    []guardian.RegistrationTokenInfo
+}
+
+// fetchLive reads everything the planner compares against.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: *guardian.Client. Client carrying the user session.
+//
+// Outputs:
+//   - *live. The live state.
+//   - err: error. Non-nil if any listing fails.
+func fetchLive(ctx context.Context, client *guardian.Client) (*live, error) {
+	var (
+		l   live
+		err error
+	)
+	if l.profile, err = client.GetProfile(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	if l.realms, err = client.ListRealms(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list realms: %w", err)
+	}
+	if l.veils, err = client.ListVeils(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list veils: %w", err)
+	}
+	if l.orgs, err = client.ListOrganisations(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list organisations: %w", err)
+	}
+	if l.teams, err = client.ListTeams(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	if l.confluxes, err = client.ListConflux(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list confluxes: %w", err)
+	}
+	if l.tokens, err = client.ListRegistrationTokens(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list registration tokens: %w", err)
+	}
+	return &l, nil
+}
+
+// NewPlan diffs the manifest against live Guardian state.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: *guardian.Client. Client carrying the user session.
+//   - m: *Manifest. The desired state.
+//   - opts: Options. Pruning and membership lookup.
+//
+// Outputs:
+//   - *Plan. The changes, creates and updates first, deletes last.
+//   - err: error. Non-nil if live state cannot be read or the manifest references unknown veils, organisations, or confluxes.
+func NewPlan(ctx context.Context, client *guardian.Client, m *Manifest, opts Options) (*Plan, error) {
+	l, err := fetchLive(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return diff(ctx, client, l, m, opts)
+}
+
+// diff computes the plan from live state that has already been read.
+//
+// Inputs:
+//   - ctx: context.Context. Request context, for reading membership.
+//   - client: *guardian.Client. Client carrying the user session; only used when opts.ConfluxTokens is set.
+//   - l: *live. The live state.
+//   - m: *Manifest. The desired state.
+//   - opts: Options. Pruning and membership lookup.
+//
+// Outputs:
+//   - *Plan. The changes, creates and updates first, deletes last.
+//   - err: error. Non-nil if the manifest references unknown veils, organisations, or confluxes.
+func diff(ctx context.Context, client *guardian.Client, l *live, m *Manifest, opts Options) (*Plan, error) {
+	p := &Plan{
+		realmIDs: make(map[string]string),
+		teamIDs:  make(map[string]string),
+	}
+	prune := m.Prune || opts.Prune
+	var realmDeletes, teamDeletes, tokenDeletes []Change
+
+	// Realms
+	declaredRealms := make(map[string]bool, len(m.Realms))
+	for _, realm := range m.Realms {
+		declaredRealms[realm.Name] = true
+		veil, err := findVeil(l.veils, realm.Veil)
+		if err != nil {
+			return nil, fmt.Errorf("realm %q: %w", realm.Name, err)
+		}
+
+		current, err := findRealm(l.realms, realm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			p.Changes = append(p.Changes, createRealm(realm, veil))
+			continue
+		}
+		p.realmIDs[realm.Name] = current.ID
+
+		// Guardian has no realm update endpoint, so any difference is a conflict
+		var diffs []string
+		if current.Subnet != realm.Subnet {
+			diffs = append(diffs, fmt.Sprintf("subnet %s -> %s", current.Subnet, realm.Subnet))
+		}
+		if current.VeilID != veil.ID {
+			diffs = append(diffs, fmt.Sprintf("veil %s -> %s", current.VeilID, veil.ID))
+		}
+		if current.Public != realm.Public {
+			diffs = append(diffs, fmt.Sprintf("public %t -> %t", current.Public, realm.Public))
+		}
+		for _, diff := range diffs {
+			p.Changes = append(p.Changes, Change{
+				Action: ActionConflict,
+				Kind:   "realm",
+				Name:   realm.Name,
+				Detail: diff + " cannot be changed in place; delete the realm or revert the manifest",
+			})
+		}
+	}
+	if prune {
+		for _, realm := range l.realms {
+			if realm.UserID == l.profile.ID && !declaredRealms[realm.Name] {
+				realmDeletes = append(realmDeletes, deleteRealm(realm))
+			}
+		}
+	}
+
+	// Teams, realm bindings, and conflux membership
+	declaredTeams := make(map[string]bool, len(m.Teams))
+	managedOrgs := make(map[string]bool)
+	var memberships []Change
+	for _, team := range m.Teams {
+		org, err := findOrganisation(l.orgs, team.Organisation)
+		if err != nil {
+			return nil, fmt.Errorf("team %q: %w", team.Key(), err)
+		}
+		managedOrgs[org.ID] = true
+		declaredTeams[org.ID+"/"+team.Name] = true
+
+		current, err := findTeam(l.teams, org.ID, team.Name)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			p.Changes = append(p.Changes, createTeam(team, org))
+		} else {
+			p.teamIDs[team.Key()] = current.ID
+			if team.Email != "" && team.Email != current.Email {
+				p.Changes = append(p.Changes, updateTeam(team, current))
+			}
+		}
+
+		if team.Realm != nil {
+			liveRealmID := ""
+			if current != nil {
+				liveRealmID = current.RealmID
+			}
+			desiredRealmID, exists := p.realmIDs[*team.Realm]
+			if *team.Realm == "" {
+				exists = true
+			}
+			if !exists || desiredRealmID != liveRealmID {
+				p.Changes = append(p.Changes, bindTeam(team))
+			}
+		}
+
+		if team.Confluxes != nil {
+			changes, err := planMembership(ctx, client, l, team, current, opts.ConfluxTokens)
+			if err != nil {
+				return nil, err
+			}
+			memberships = append(memberships, changes...)
+		}
+	}
+	p.Changes = append(p.Changes, memberships...)
+	if prune {
+		for _, team := range l.teams {
+			if managedOrgs[team.OrganisationID] && !declaredTeams[team.OrganisationID+"/"+team.Name] {
+				teamDeletes = append(teamDeletes, deleteTeam(team))
+			}
+		}
+	}
+
+	// Registration tokens
+	now := time.Now()
+	declaredTokens := make(map[string]bool, len(m.Tokens))
+	for _, token := range m.Tokens {
+		declaredTokens[token.Key()] = true
+		realmID, exists := p.realmIDs[token.Realm]
+		if !exists {
+			p.Changes = append(p.Changes, createToken(token, "realm is created by this plan"))
+			continue
+		}
+
+		var newest, unreadable *guardian.RegistrationTokenInfo
+		for i, info := range l.tokens {
+			if info.RealmID != realmID || info.Tag != token.Tag {
+				continue
+			}
+			// A token whose expiry cannot be read is not trusted to be valid
+			if info.ExpiresAt.IsZero() {
+				unreadable = &l.tokens[i]
+				continue
+			}
+			if info.ExpiresAt.After(now) && (newest == nil || info.ExpiresAt.After(newest.ExpiresAt.Time)) {
+				newest = &l.tokens[i]
+			}
+		}
+		switch {
+		case newest == nil && unreadable != nil:
+			p.Changes = append(p.Changes, createToken(token, fmt.Sprintf("token %s has an unreadable expiry %q", unreadable.TokenID, unreadable.ExpiresAt.Raw)))
+		case newest == nil:
+			p.Changes = append(p.Changes, createToken(token, "no valid token"))
+		case token.RenewBefore > 0 && newest.ExpiresAt.Sub(now) < token.RenewBefore:
+			p.Changes = append(p.Changes, createToken(token, fmt.Sprintf("token %s expires at %s", newest.TokenID, newest.ExpiresAt.Format(time.RFC3339))))
+		}
+	}
+	if prune {
+		realmNames := make(map[string]string, len(p.realmIDs))
+		for name, id := range p.realmIDs {
+			realmNames[id] = name
+		}
+		for _, info := range l.tokens {
+			name, managed := realmNames[info.RealmID]
+			if !managed || info.UserID != l.profile.ID || !info.ExpiresAt.After(now) {
+				continue
+			}
+			if !declaredTokens[name+"/"+info.Tag] {
+				tokenDeletes = append(tokenDeletes, deleteToken(name, info))
+			}
+		}
+	}
+
+	// Deletes run last: tokens, then teams, then realms
+	p.Changes = append(p.Changes, tokenDeletes...)
+	p.Changes = append(p.Changes, teamDeletes...)
+	p.Changes = append(p.Changes, realmDeletes...)
+	return p, nil
+}
+
+// planMembership diffs a team's desired confluxes against what Guardian can report.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: *guardian.Client. Client carrying the user session.
+//   - l: *live. The live state.
+//   - team: Team. The desired team.
+//   - current: *guardian.Team. The live team, nil if it does not exist yet.
+//   - tokens: map[string]string. Conflux tokens by conflux ID.
+//
+// Outputs:
+//   - []Change. Membership additions, ensures, and removals.
+//   - err: error. Non-nil if a conflux reference is unknown or ambiguous, or membership cannot be read.
+func planMembership(ctx context.Context, client *guardian.Client, l *live, team Team, current *guardian.Team, tokens map[string]string) ([]Change, error) {
+	var changes []Change
+	desired := make(map[string]bool, len(team.Confluxes))
+	for _, ref := range team.Confluxes {
+		conflux, err := findConflux(l.confluxes, ref)
+		if err != nil {
+			return nil, fmt.Errorf("team %q: %w", team.Key(), err)
+		}
+		desired[conflux.ID] = true
+
+		if current == nil {
+			changes = append(changes, addMember(team, conflux, ActionCreate))
+			continue
+		}
+		token, known := tokens[conflux.ID]
+		if !known {
+			changes = append(changes, addMember(team, conflux, ActionEnsure))
+			continue
+		}
+		teams, err := confluxTeams(ctx, client, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read teams of conflux %s: %w", conflux.ID, err)
+		}
+		if !teams[current.ID] {
+			changes = append(changes, addMember(team, conflux, ActionCreate))
+		}
+	}
+
+	// Removals are only possible where membership can be read
+	if current == nil {
+		return changes, nil
+	}
+	for id, token := range tokens {
+		if desired[id] {
+			continue
+		}
+		teams, err := confluxTeams(ctx, client, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read teams of conflux %s: %w", id, err)
+		}
+		if teams[current.ID] {
+			changes = append(changes, removeMember(team, id))
+		}
+	}
+	return changes, nil
+}
+
+// confluxTeams reads the team IDs a conflux belongs to using its conflux token.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - client: *guardian.Client. Any client; only its base URL is reused.
+//   - token: string. The conflux token.
+//
+// Outputs:
+//   - map[string]bool. The team IDs.
+//   - err: error. Non-nil if the request fails or the response is not a list of teams.
+func confluxTeams(ctx context.Context, client *guardian.Client, token string) (map[string]bool, error) {
+	raw, err := guardian.NewClient(client.BaseURL()).WithConfluxToken(token).ListConfluxTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make(map[string]bool)
+	var ids []string
+	if err := json.Unmarshal(raw, &ids); err == nil {
+		for _, id := range ids {
+			teams[id] = true
+		}
+		return teams, nil
+	}
+	var entries []struct {
+		ID     string `json:"id"`
+		TeamID string `json:"team_id"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("unexpected team list: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.TeamID != "" {
+			teams[entry.TeamID] = true
+		} else {
+			teams[entry.ID] = true
+		}
+	}
+	return teams, nil
+}
+
+// findVeil resolves a veil by ID or name.
+func findVeil(veils []guardian.Veil, ref string) (*guardian.Veil, error) {
+	var found *guardian.Veil
+	for i, veil := range veils {
+		if veil.ID == ref {
+			return &veils[i], nil
+		}
+		if veil.Name == ref {
+			if found != nil {
+				return nil, fmt.Errorf("veil name %q is ambiguous, use its ID", ref)
+			}
+			found = &veils[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("veil %q not found", ref)
+	}
+	return found, nil
+}
+
+// findOrganisation resolves an organisation by ID or name.
+func findOrganisation(orgs []guardian.Organisation, ref string) (*guardian.Organisation, error) {
+	var found *guardian.Organisation
+	for i, org := range orgs {
+		if org.ID == ref {
+			return &orgs[i], nil
+		}
+		if org.Name == ref {
+			if found != nil {
+				return nil, fmt.Errorf("organisation name %q is ambiguous, use its ID", ref)
+			}
+			found = &orgs[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("organisation %q not found", ref)
+	}
+	return found, nil
+}
+
+// findConflux resolves a conflux by ID or tag.
+func findConflux(confluxes []guardian.Conflux, ref string) (*guardian.Conflux, error) {
+	var found *guardian.Conflux
+	for i, conflux := range confluxes {
+		if conflux.ID == ref {
+			return &confluxes[i], nil
+		}
+		if conflux.Tag == ref {
+			if found != nil {
+				return nil, fmt.Errorf("conflux tag %q is ambiguous, use its ID", ref)
+			}
+			found = &confluxes[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("conflux %q not found", ref)
+	}
+	return found, nil
+}
+
+// findRealm returns the live realm with the given name, nil if there is none.
+func findRealm(realms []guardian.Realm, name string) (*guardian.Realm, error) {
+	var found *guardian.Realm
+	for i, realm := range realms {
+		if realm.Name == name {
+			if found != nil {
+				return nil, fmt.Errorf("realm name %q matches several live realms", name)
+			}
+			found = &realms[i]
+		}
+	}
+	return found, nil
+}
+
+// findTeam returns the live team with the given organisation and name, nil if there is none.
+func findTeam(teams []guardian.Team, orgID string, name string) (*guardian.Team, error) {
+	var found *guardian.Team
+	for i, team := range teams {
+		if team.OrganisationID == orgID && team.Name == name {
+			if found != nil {
+				return nil, fmt.Errorf("team name %q matches several live teams in organisation %s", name, orgID)
+			}
+			found = &teams[i]
+		}
+	}
+	return found, nil
+}
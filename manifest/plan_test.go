@@ -0,0 +1,273 @@
+package manifest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veil-net/conflux/guardian"
+)
+
+// testLive returns live state with one realm owned by the user, and a veil, organisation, team, and conflux to refer to.
+func testLive() *live {
+	return &live{
+		profile: &guardian.UserProfile{ID: "user"},
+		realms: []guardian.Realm{
+			{ID: "realm-prod", UserID: "user", VeilID: "veil-eu", Name: "prod", Subnet: "10.1.0.0/16"},
+		},
+		veils: []guardian.Veil{
+			{ID: "veil-eu", Name: "eu"},
+			{ID: "veil-us", Name: "us"},
+		},
+		orgs: []guardian.Organisation{
+			{ID: "org-acme", Name: "acme"},
+		},
+		teams: []guardian.Team{
+			{ID: "team-ops", OrganisationID: "org-acme", Name: "ops", Email: "ops@acme.test", RealmID: "realm-prod"},
+		},
+		confluxes: []guardian.Conflux{
+			{ID: "conflux-edge", Tag: "edge"},
+		},
+	}
+}
+
+// testManifest returns a manifest that matches testLive exactly.
+func testManifest() *Manifest {
+	return &Manifest{
+		Realms: []Realm{{Name: "prod", Subnet: "10.1.0.0/16", Veil: "eu"}},
+		Teams:  []Team{{Name: "ops", Organisation: "acme", Email: "ops@acme.test"}},
+	}
+}
+
+// summarize renders changes as "action kind name" for comparison.
+func summarize(changes []Change) []string {
+	summary := make([]string, len(changes))
+	for i, change := range changes {
+		summary[i] = string(change.Action) + " " + change.Kind + " " + change.Name
+	}
+	return summary
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	token := func(id, realmID, tag string, expires time.Time) guardian.RegistrationTokenInfo {
+		return guardian.RegistrationTokenInfo{TokenID: id, UserID: "user", RealmID: realmID, Tag: tag, ExpiresAt: guardian.Timestamp{Time: expires}}
+	}
+	realm := func(name string) *string { return &name }
+
+	tests := []struct {
+		name   string
+		modify func(l *live, m *Manifest)
+		opts   Options
+		want   []string
+	}{
+		{"in sync", func(l *live, m *Manifest) {}, Options{}, []string{}},
+		{"missing realm", func(l *live, m *Manifest) {
+			m.Realms = append(m.Realms, Realm{Name: "dev", Subnet: "10.2.0.0/16", Veil: "veil-us"})
+		}, Options{}, []string{"create realm dev"}},
+		{"realm subnet changed", func(l *live, m *Manifest) { m.Realms[0].Subnet = "10.9.0.0/16" }, Options{}, []string{"conflict realm prod"}},
+		{"realm veil and public changed", func(l *live, m *Manifest) {
+			m.Realms[0].Veil = "us"
+			m.Realms[0].Public = true
+		}, Options{}, []string{"conflict realm prod", "conflict realm prod"}},
+		{"undeclared realm kept", func(l *live, m *Manifest) {
+			l.realms = append(l.realms, guardian.Realm{ID: "realm-old", UserID: "user", Name: "old"})
+		}, Options{}, []string{}},
+		{"undeclared realm pruned", func(l *live, m *Manifest) {
+			l.realms = append(l.realms, guardian.Realm{ID: "realm-old", UserID: "user", Name: "old"})
+		}, Options{Prune: true}, []string{"delete realm old"}},
+		{"realm of another user not pruned", func(l *live, m *Manifest) {
+			l.realms = append(l.realms, guardian.Realm{ID: "realm-shared", UserID: "other", Name: "shared"})
+			m.Prune = true
+		}, Options{}, []string{}},
+		{"missing team", func(l *live, m *Manifest) {
+			m.Teams = append(m.Teams, Team{Name: "dev", Organisation: "org-acme"})
+		}, Options{}, []string{"create team org-acme/dev"}},
+		{"team email changed", func(l *live, m *Manifest) { m.Teams[0].Email = "noc@acme.test" }, Options{}, []string{"update team acme/ops"}},
+		{"team bound to its realm", func(l *live, m *Manifest) { m.Teams[0].Realm = realm("prod") }, Options{}, []string{}},
+		{"team unbound", func(l *live, m *Manifest) { m.Teams[0].Realm = realm("") }, Options{}, []string{"update binding acme/ops"}},
+		{"team bound to a new realm", func(l *live, m *Manifest) {
+			m.Realms = append(m.Realms, Realm{Name: "dev", Subnet: "10.2.0.0/16", Veil: "eu"})
+			m.Teams[0].Realm = realm("dev")
+		}, Options{}, []string{"create realm dev", "update binding acme/ops"}},
+		{"undeclared team pruned", func(l *live, m *Manifest) {
+			l.teams = append(l.teams, guardian.Team{ID: "team-old", OrganisationID: "org-acme", Name: "old"})
+		}, Options{Prune: true}, []string{"delete team org-acme/old"}},
+		{"team in an unmanaged organisation not pruned", func(l *live, m *Manifest) {
+			l.teams = append(l.teams, guardian.Team{ID: "team-other", OrganisationID: "org-other", Name: "other"})
+		}, Options{Prune: true}, []string{}},
+		{"membership without a conflux token is ensured", func(l *live, m *Manifest) {
+			m.Teams[0].Confluxes = []string{"edge"}
+		}, Options{}, []string{"ensure membership acme/ops"}},
+		{"membership of a new team is created", func(l *live, m *Manifest) {
+			m.Teams = append(m.Teams, Team{Name: "dev", Organisation: "acme", Confluxes: []string{"conflux-edge"}})
+		}, Options{}, []string{"create team acme/dev", "create membership acme/dev"}},
+		{"missing token", func(l *live, m *Manifest) {
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge"}}
+		}, Options{}, []string{"create token prod/edge"}},
+		{"valid token", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{token("t1", "realm-prod", "edge", now.Add(time.Hour))}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge"}}
+		}, Options{}, []string{}},
+		{"expired token", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{token("t1", "realm-prod", "edge", now.Add(-time.Hour))}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge"}}
+		}, Options{}, []string{"create token prod/edge"}},
+		{"token of another tag", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{token("t1", "realm-prod", "core", now.Add(time.Hour))}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge"}}
+		}, Options{}, []string{"create token prod/edge"}},
+		{"token due for renewal", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{token("t1", "realm-prod", "edge", now.Add(time.Hour))}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge", RenewBefore: 2 * time.Hour}}
+		}, Options{}, []string{"create token prod/edge"}},
+		{"newest token decides renewal", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{
+				token("t1", "realm-prod", "edge", now.Add(time.Hour)),
+				token("t2", "realm-prod", "edge", now.Add(5*time.Hour)),
+			}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge", RenewBefore: 2 * time.Hour}}
+		}, Options{}, []string{}},
+		{"token with an unreadable expiry", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{{TokenID: "t1", UserID: "user", RealmID: "realm-prod", Tag: "edge", ExpiresAt: guardian.Timestamp{Raw: "soon"}}}
+			m.Tokens = []Token{{Realm: "prod", Tag: "edge"}}
+		}, Options{}, []string{"create token prod/edge"}},
+		{"token for a new realm", func(l *live, m *Manifest) {
+			m.Realms = append(m.Realms, Realm{Name: "dev", Subnet: "10.2.0.0/16", Veil: "eu"})
+			m.Tokens = []Token{{Realm: "dev"}}
+		}, Options{}, []string{"create realm dev", "create token dev/"}},
+		{"undeclared token pruned", func(l *live, m *Manifest) {
+			l.tokens = []guardian.RegistrationTokenInfo{
+				token("t1", "realm-prod", "old", now.Add(time.Hour)),
+				token("t2", "realm-prod", "expired", now.Add(-time.Hour)),
+				token("t3", "realm-other", "old", now.Add(time.Hour)),
+			}
+		}, Options{Prune: true}, []string{"delete token prod/old"}},
+		{"deletes ordered last", func(l *live, m *Manifest) {
+			l.realms = append(l.realms, guardian.Realm{ID: "realm-old", UserID: "user", Name: "old"})
+			l.teams = append(l.teams, guardian.Team{ID: "team-old", OrganisationID: "org-acme", Name: "old"})
+			l.tokens = []guardian.RegistrationTokenInfo{token("t1", "realm-prod", "old", now.Add(time.Hour))}
+			m.Realms = append(m.Realms, Realm{Name: "dev", Subnet: "10.2.0.0/16", Veil: "eu"})
+		}, Options{Prune: true}, []string{"create realm dev", "delete token prod/old", "delete team org-acme/old", "delete realm old"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, m := testLive(), testManifest()
+			test.modify(l, m)
+			plan, err := diff(context.Background(), nil, l, m, test.opts)
+			if err != nil {
+				t.Fatalf("diff: %v", err)
+			}
+			if got := summarize(plan.Changes); !slices.Equal(got, test.want) {
+				t.Errorf("changes = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffTokenReason(t *testing.T) {
+	l, m := testLive(), testManifest()
+	l.tokens = []guardian.RegistrationTokenInfo{{TokenID: "t1", UserID: "user", RealmID: "realm-prod", ExpiresAt: guardian.Timestamp{Raw: "soon"}}}
+	m.Tokens = []Token{{Realm: "prod"}}
+	plan, err := diff(context.Background(), nil, l, m, Options{})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(plan.Changes) != 1 || !strings.Contains(plan.Changes[0].Detail, `token t1 has an unreadable expiry "soon"`) {
+		t.Errorf("changes = %+v, want a create naming the unreadable token", plan.Changes)
+	}
+}
+
+func TestDiffUnknownReferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(l *live, m *Manifest)
+		want   string
+	}{
+		{"unknown veil", func(l *live, m *Manifest) { m.Realms[0].Veil = "ap" }, `veil "ap" not found`},
+		{"ambiguous veil", func(l *live, m *Manifest) {
+			l.veils = append(l.veils, guardian.Veil{ID: "veil-eu-2", Name: "eu"})
+		}, `veil name "eu" is ambiguous`},
+		{"unknown organisation", func(l *live, m *Manifest) { m.Teams[0].Organisation = "globex" }, `organisation "globex" not found`},
+		{"unknown conflux", func(l *live, m *Manifest) { m.Teams[0].Confluxes = []string{"core"} }, `conflux "core" not found`},
+		{"duplicate live realm", func(l *live, m *Manifest) {
+			l.realms = append(l.realms, guardian.Realm{ID: "realm-prod-2", Name: "prod"})
+		}, `realm name "prod" matches several live realms`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, m := testLive(), testManifest()
+			test.modify(l, m)
+			_, err := diff(context.Background(), nil, l, m, Options{})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("diff error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestPlanCounts(t *testing.T) {
+	plan := &Plan{Changes: []Change{
+		{Action: ActionCreate}, {Action: ActionEnsure}, {Action: ActionConflict}, {Action: ActionCreate},
+	}}
+	if got := plan.Drift(); got != 3 {
+		t.Errorf("Drift() = %d, want 3", got)
+	}
+	if got := plan.Count(ActionCreate); got != 2 {
+		t.Errorf("Count(create) = %d, want 2", got)
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	realm := func(name string) *string { return &name }
+	tests := []struct {
+		name   string
+		modify func(m *Manifest)
+		want   []string
+	}{
+		{"valid", func(m *Manifest) {}, nil},
+		{"realm without name", func(m *Manifest) { m.Realms[0].Name = "" }, []string{"realms[0]: name is required"}},
+		{"duplicate realm", func(m *Manifest) { m.Realms = append(m.Realms, m.Realms[0]) }, []string{`realms[1]: duplicate realm "prod"`}},
+		{"bad subnet", func(m *Manifest) { m.Realms[0].Subnet = "10.1.0.0" }, []string{`subnet "10.1.0.0" is not a CIDR`}},
+		{"realm without veil", func(m *Manifest) { m.Realms[0].Veil = "" }, []string{`realm "prod": veil is required`}},
+		{"team without organisation", func(m *Manifest) { m.Teams[0].Organisation = "" }, []string{"teams[0]: name and organisation are required"}},
+		{"duplicate team", func(m *Manifest) { m.Teams = append(m.Teams, m.Teams[0]) }, []string{`teams[1]: duplicate team "acme/ops"`}},
+		{"team bound to an undeclared realm", func(m *Manifest) { m.Teams[0].Realm = realm("dev") }, []string{`realm "dev" is not declared`}},
+		{"team unbound", func(m *Manifest) { m.Teams[0].Realm = realm("") }, nil},
+		{"token without realm", func(m *Manifest) { m.Tokens = []Token{{Tag: "edge"}} }, []string{"tokens[0]: realm is required"}},
+		{"token for an undeclared realm", func(m *Manifest) { m.Tokens = []Token{{Realm: "dev"}} }, []string{`tokens[0]: realm "dev" is not declared`}},
+		{"duplicate token", func(m *Manifest) { m.Tokens = []Token{{Realm: "prod"}, {Realm: "prod"}} }, []string{`tokens[1]: duplicate token "prod/"`}},
+		{"negative expiry", func(m *Manifest) { m.Tokens = []Token{{Realm: "prod", Expires: -time.Hour}} }, []string{"must not be negative"}},
+		{"renewal longer than expiry", func(m *Manifest) {
+			m.Tokens = []Token{{Realm: "prod", Expires: time.Hour, RenewBefore: 2 * time.Hour}}
+		}, []string{"renew_before must be shorter than expires"}},
+		{"renewal with the default expiry", func(m *Manifest) { m.Tokens = []Token{{Realm: "prod", RenewBefore: 2 * time.Hour}} }, nil},
+		{"all problems reported", func(m *Manifest) {
+			m.Realms[0].Veil = ""
+			m.Teams[0].Name = ""
+		}, []string{"veil is required", "name and organisation are required"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := testManifest()
+			test.modify(m)
+			err := m.Validate()
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %q", test.want)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
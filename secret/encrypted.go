@@ -0,0 +1,187 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// PassphraseEnv holds the passphrase of encrypted-file secrets.
+	PassphraseEnv = "VEILNET_SECRET_PASSPHRASE"
+	// PassphraseFileEnv names a file holding the passphrase, used when PassphraseEnv is unset.
+	PassphraseFileEnv = "VEILNET_SECRET_PASSPHRASE_FILE"
+
+	// encryptedIterations is the PBKDF2-SHA256 work factor for new files.
+	encryptedIterations = 600000
+)
+
+// EncryptedFileBackend stores each secret in a file encrypted with AES-256-GCM under a PBKDF2-derived key.
+// The passphrase comes from VEILNET_SECRET_PASSPHRASE or the file named by VEILNET_SECRET_PASSPHRASE_FILE.
+type EncryptedFileBackend struct{}
+
+func init() {
+	Register("encrypted-file", EncryptedFileBackend{})
+}
+
+// encryptedFile is the on-disk format of an encrypted secret.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Get reads and decrypts the file.
+func (EncryptedFileBackend) Get(ctx context.Context, ref Ref) (string, error) {
+	path, err := filePath(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPrivate(path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	passphrase, err := passphrase()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Open(passphrase, data, []byte(ref.Path))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Set encrypts value with a fresh salt and nonce and writes the file with mode 0600.
+func (EncryptedFileBackend) Set(ctx context.Context, ref Ref, value string) error {
+	path, err := filePath(ref)
+	if err != nil {
+		return err
+	}
+	passphrase, err := passphrase()
+	if err != nil {
+		return err
+	}
+	// The path is bound as additional data so a file cannot be swapped for another
+	data, err := Seal(passphrase, []byte(value), []byte(ref.Path))
+	if err != nil {
+		return err
+	}
+	return writePrivate(path, data)
+}
+
+// Delete removes the file.
+func (EncryptedFileBackend) Delete(ctx context.Context, ref Ref) error {
+	path, err := filePath(ref)
+	if err != nil {
+		return err
+	}
+	return removeFile(path)
+}
+
+// Seal encrypts plaintext with AES-256-GCM under a key derived from passphrase with PBKDF2-SHA256 and a fresh salt,
+// in the format of encrypted-file secrets.
+//
+// Inputs:
+//   - passphrase: string. The passphrase.
+//   - plaintext: []byte. The data to encrypt.
+//   - additionalData: []byte. Authenticated but not encrypted; Open must be given the same value.
+//
+// Outputs:
+//   - []byte. The encrypted document.
+//   - err: error. Non-nil if key derivation or the random source fails.
+func Seal(passphrase string, plaintext []byte, additionalData []byte) ([]byte, error) {
+	file := encryptedFile{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: encryptedIterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := encryptedAEAD(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return nil, err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, additionalData)
+	return json.Marshal(&file)
+}
+
+// Open decrypts a document produced by Seal.
+//
+// Inputs:
+//   - passphrase: string. The passphrase.
+//   - data: []byte. The encrypted document.
+//   - additionalData: []byte. The value given to Seal.
+//
+// Outputs:
+//   - []byte. The plaintext.
+//   - err: error. Non-nil if the document is malformed, the passphrase is wrong, or the data was tampered with.
+func Open(passphrase string, data []byte, additionalData []byte) ([]byte, error) {
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("not an encrypted secret file: %w", err)
+	}
+	if file.Version != 1 || file.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported encrypted secret format %d/%s", file.Version, file.KDF)
+	}
+	aead, err := encryptedAEAD(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.New("failed to decrypt, wrong passphrase or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// encryptedAEAD derives the AES-256-GCM cipher for a passphrase and salt.
+func encryptedAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// passphrase returns the passphrase from the environment or the passphrase file.
+func passphrase() (string, error) {
+	if value := os.Getenv(PassphraseEnv); value != "" {
+		return value, nil
+	}
+	if path := os.Getenv(PassphraseFileEnv); path != "" {
+		if err := checkPrivate(path); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return "", fmt.Errorf("encrypted secrets need a passphrase in %s or %s", PassphraseEnv, PassphraseFileEnv)
+}
@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	plaintext := []byte("conflux token")
+	aad := []byte("/var/lib/conflux/token.enc")
+	sealed, err := Seal("correct horse", plaintext, aad)
+	if err != nil {
+		t.Fatalf("Seal error = %v", err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Fatal("sealed document contains the plaintext")
+	}
+
+	tampered := tamperCiphertext(t, sealed)
+	tests := []struct {
+		name       string
+		passphrase string
+		data       []byte
+		aad        []byte
+		wantErr    bool
+	}{
+		{"round trip", "correct horse", sealed, aad, false},
+		{"wrong passphrase", "battery staple", sealed, aad, true},
+		{"other additional data", "correct horse", sealed, []byte("/var/lib/conflux/other.enc"), true},
+		{"missing additional data", "correct horse", sealed, nil, true},
+		{"tampered ciphertext", "correct horse", tampered, aad, true},
+		{"not a document", "correct horse", []byte("plain"), aad, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.passphrase, tt.data, tt.aad)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, plaintext) {
+				t.Errorf("Open = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshSaltAndNonce(t *testing.T) {
+	a, err := Seal("pass", []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Seal("pass", []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("two Seal calls produced the same document")
+	}
+}
+
+func TestEncryptedFileBackend(t *testing.T) {
+	t.Setenv(PassphraseEnv, "correct horse")
+	t.Setenv(PassphraseFileEnv, "")
+	ctx := context.Background()
+	dir := t.TempDir()
+	ref := fileRef("encrypted-file", filepath.Join(dir, "token.enc"))
+	backend := EncryptedFileBackend{}
+
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "token.enc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatal("encrypted file contains the plaintext")
+	}
+	got, err := backend.Get(ctx, ref)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+
+	// The path is bound as additional data, so a copy under another name does not decrypt
+	swapped := filepath.Join(dir, "other.enc")
+	if err := os.WriteFile(swapped, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Get(ctx, fileRef("encrypted-file", swapped)); err == nil {
+		t.Error("Get of a file moved to another path error = nil")
+	}
+
+	t.Setenv(PassphraseEnv, "battery staple")
+	if _, err := backend.Get(ctx, ref); err == nil {
+		t.Error("Get with the wrong passphrase error = nil")
+	}
+}
+
+func TestEncryptedFileBackendPassphraseFile(t *testing.T) {
+	dir := t.TempDir()
+	passphraseFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PassphraseEnv, "")
+	t.Setenv(PassphraseFileEnv, passphraseFile)
+	ctx := context.Background()
+	ref := fileRef("encrypted-file", filepath.Join(dir, "token.enc"))
+
+	if err := (EncryptedFileBackend{}).Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	got, err := EncryptedFileBackend{}.Get(ctx, ref)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+
+	t.Setenv(PassphraseFileEnv, "")
+	if _, err := (EncryptedFileBackend{}).Get(ctx, ref); err == nil {
+		t.Error("Get without a passphrase error = nil")
+	}
+}
+
+// tamperCiphertext flips one bit of the ciphertext in a sealed document.
+func tamperCiphertext(t *testing.T, sealed []byte) []byte {
+	t.Helper()
+	var file encryptedFile
+	if err := json.Unmarshal(sealed, &file); err != nil {
+		t.Fatal(err)
+	}
+	file.Ciphertext[0] ^= 1
+	tampered, err := json.Marshal(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tampered
+}
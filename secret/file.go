@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FileBackend stores each secret as the whole content of a file readable only by its owner.
+type FileBackend struct{}
+
+func init() {
+	Register("file", FileBackend{})
+}
+
+// Get reads the file, refusing it if other users can read it.
+func (FileBackend) Get(ctx context.Context, ref Ref) (string, error) {
+	path, err := filePath(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPrivate(path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Set writes the file with mode 0600, creating its directory with mode 0700.
+func (FileBackend) Set(ctx context.Context, ref Ref, value string) error {
+	path, err := filePath(ref)
+	if err != nil {
+		return err
+	}
+	return writePrivate(path, []byte(value))
+}
+
+// Delete removes the file.
+func (FileBackend) Delete(ctx context.Context, ref Ref) error {
+	path, err := filePath(ref)
+	if err != nil {
+		return err
+	}
+	return removeFile(path)
+}
+
+// filePath returns the absolute file path of a file-based reference.
+func filePath(ref Ref) (string, error) {
+	path := filepath.FromSlash(ref.Path)
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("%s: file path must be absolute, e.g. %s://%s//etc/conflux/token", ref, Scheme, ref.Backend)
+	}
+	return path, nil
+}
+
+// checkPrivate refuses files other users can access; Windows relies on ACLs instead of mode bits.
+//
+// Inputs:
+//   - path: string. The file.
+//
+// Outputs:
+//   - err: error. Wraps ErrNotFound if the file is missing; non-nil if group or others have any access.
+func checkPrivate(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s has mode %04o, run `chmod 600 %s`", path, info.Mode().Perm(), path)
+	}
+	return nil
+}
+
+// writePrivate writes data to path with mode 0600, creating missing parent directories with mode 0700.
+//
+// Inputs:
+//   - path: string. The file.
+//   - data: []byte. The content.
+//
+// Outputs:
+//   - err: error. Non-nil if the directory or file cannot be written.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, so tighten it explicitly
+	return os.Chmod(path, 0600)
+}
+
+// removeFile removes path; a missing file is not an error.
+func removeFile(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fileRef returns a reference to path for backend.
+func fileRef(backend string, path string) Ref {
+	return Ref{Backend: backend, Path: filepath.ToSlash(path)}
+}
+
+func TestFileBackendRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "token")
+	ref := fileRef("file", path)
+	backend := FileBackend{}
+
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing file error = %v, want ErrNotFound", err)
+	}
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	got, err := backend.Get(ctx, ref)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+
+	if runtime.GOOS != "windows" {
+		for _, p := range []string{path, filepath.Dir(path)} {
+			info, err := os.Stat(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := os.FileMode(0600)
+			if info.IsDir() {
+				want = 0700
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("%s has mode %04o, want %04o", p, info.Mode().Perm(), want)
+			}
+		}
+	}
+
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Errorf("Delete of a missing file error = %v, want nil", err)
+	}
+}
+
+func TestFileBackendTrimsTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FileBackend{}.Get(context.Background(), fileRef("file", path))
+	if err != nil || got != "s3cret" {
+		t.Errorf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+}
+
+func TestFileBackendRefusesSharedFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows relies on ACLs instead of mode bits")
+	}
+	for _, mode := range []os.FileMode{0640, 0604, 0644} {
+		path := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(path, []byte("s3cret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (FileBackend{}).Get(context.Background(), fileRef("file", path)); err == nil {
+			t.Errorf("Get of a file with mode %04o error = nil, want a refusal", mode)
+		}
+	}
+}
+
+func TestFileBackendTightensExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows relies on ACLs instead of mode bits")
+	}
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (FileBackend{}).Set(context.Background(), fileRef("file", path), "new"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Set left mode %04o, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFileBackendRejectsRelativePath(t *testing.T) {
+	if _, err := (FileBackend{}).Get(context.Background(), Ref{Backend: "file", Path: "etc/token"}); err == nil {
+		t.Error("Get with a relative path error = nil")
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// KeyringBackend stores secrets as "user" keys in the Linux kernel user keyring of the current user.
+// Kernel keys do not survive a reboot, so unattended nodes should prefer secret-service or vault.
+type KeyringBackend struct{}
+
+func init() {
+	Register("keyring", KeyringBackend{})
+}
+
+// Get reads the key whose description is the reference path.
+func (KeyringBackend) Get(ctx context.Context, ref Ref) (string, error) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", ref.Path, 0)
+	if errors.Is(err, unix.ENOKEY) {
+		return "", fmt.Errorf("%w: key %q", ErrNotFound, ref.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// KeyctlBuffer returns the full payload size, so retry if the buffer was too small
+	buffer := make([]byte, 4096)
+	for {
+		size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buffer, 0)
+		if err != nil {
+			return "", err
+		}
+		if size <= len(buffer) {
+			return string(buffer[:size]), nil
+		}
+		buffer = make([]byte, size)
+	}
+}
+
+// Set adds or updates the key; add_key replaces the payload of an existing key with the same description.
+func (KeyringBackend) Set(ctx context.Context, ref Ref, value string) error {
+	id, err := unix.AddKey("user", ref.Path, []byte(value), unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		return err
+	}
+	// Only the possessor and the owning user may read the key
+	const perm = 0x3f3f0000
+	_, err = unix.KeyctlInt(unix.KEYCTL_SETPERM, id, perm, 0, 0)
+	return err
+}
+
+// Delete unlinks the key from the user keyring.
+func (KeyringBackend) Delete(ctx context.Context, ref Ref) error {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", ref.Path, 0)
+	if errors.Is(err, unix.ENOKEY) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_UNLINK, id, unix.KEY_SPEC_USER_KEYRING, 0, 0)
+	return err
+}
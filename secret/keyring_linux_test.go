@@ -0,0 +1,51 @@
+//go:build linux
+
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestKeyringBackend(t *testing.T) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ref := Ref{Backend: "keyring", Path: "conflux-test-" + hex.EncodeToString(suffix)}
+	backend := KeyringBackend{}
+
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+			t.Skipf("kernel keyring unavailable: %v", err)
+		}
+		t.Fatalf("Set error = %v", err)
+	}
+	t.Cleanup(func() { backend.Delete(ctx, ref) })
+
+	got, err := backend.Get(ctx, ref)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+	if err := backend.Set(ctx, ref, "rotated"); err != nil {
+		t.Fatalf("Set of an existing key error = %v", err)
+	}
+	if got, err := backend.Get(ctx, ref); err != nil || got != "rotated" {
+		t.Fatalf("Get after update = %q, %v; want %q", got, err, "rotated")
+	}
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Errorf("Delete of a missing key error = %v, want nil", err)
+	}
+}
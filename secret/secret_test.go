@@ -0,0 +1,70 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Ref
+		wantErr bool
+	}{
+		{"secret://file//etc/conflux/token", Ref{Backend: "file", Path: "/etc/conflux/token"}, false},
+		{"secret://encrypted-file//var/lib/conflux/token.enc", Ref{Backend: "encrypted-file", Path: "/var/lib/conflux/token.enc"}, false},
+		{"secret://keyring/conflux-token", Ref{Backend: "keyring", Path: "conflux-token"}, false},
+		{"secret://secret-service/conflux/token", Ref{Backend: "secret-service", Path: "conflux/token"}, false},
+		{"secret://vault/secret/conflux#token", Ref{Backend: "vault", Path: "secret/conflux", Field: "token"}, false},
+		{"plain-token", Ref{}, true},
+		{"https://vault/secret/conflux", Ref{}, true},
+		{"secret://vault", Ref{}, true},
+		{"secret:///etc/conflux/token", Ref{}, true},
+		{"secret://vault/%zz", Ref{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefStringRoundTrip(t *testing.T) {
+	for _, value := range []string{
+		"secret://file//etc/conflux/token",
+		"secret://keyring/conflux-token",
+		"secret://vault/secret/conflux#token",
+	} {
+		ref, err := Parse(value)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", value, err)
+		}
+		if got := ref.String(); got != value {
+			t.Errorf("Parse(%q).String() = %q", value, got)
+		}
+	}
+}
+
+func TestResolveLiteral(t *testing.T) {
+	got, err := Resolve(context.Background(), "plain-token")
+	if err != nil || got != "plain-token" {
+		t.Errorf("Resolve(literal) = %q, %v; want the literal unchanged", got, err)
+	}
+}
+
+func TestResolveUnknownBackend(t *testing.T) {
+	_, err := Resolve(context.Background(), "secret://nope/x")
+	if err == nil {
+		t.Fatal("Resolve with an unknown backend error = nil")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve with an unknown backend error = %v, should not be ErrNotFound", err)
+	}
+}
@@ -0,0 +1,74 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SecretServiceBackend stores secrets in the freedesktop Secret Service (GNOME Keyring, KWallet) through secret-tool.
+// The reference path is used as the "conflux" lookup attribute.
+type SecretServiceBackend struct{}
+
+func init() {
+	Register("secret-service", SecretServiceBackend{})
+}
+
+// Get looks the secret up by its attribute.
+func (SecretServiceBackend) Get(ctx context.Context, ref Ref) (string, error) {
+	out, err := secretTool(ctx, nil, "lookup", "conflux", ref.Path)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(out) == 0 {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref.Path)
+		}
+		return "", err
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+// Set stores the secret, replacing any item with the same attribute.
+func (SecretServiceBackend) Set(ctx context.Context, ref Ref, value string) error {
+	_, err := secretTool(ctx, strings.NewReader(value), "store", "--label", "VeilNet Conflux "+ref.Path, "conflux", ref.Path)
+	return err
+}
+
+// Delete clears the item with the attribute.
+func (SecretServiceBackend) Delete(ctx context.Context, ref Ref) error {
+	_, err := secretTool(ctx, nil, "clear", "conflux", ref.Path)
+	return err
+}
+
+// secretTool runs secret-tool and returns its stdout.
+//
+// Inputs:
+//   - ctx: context.Context. Cancels the command.
+//   - stdin: *strings.Reader. Optional input, used for the secret when storing.
+//   - args: ...string. The secret-tool arguments.
+//
+// Outputs:
+//   - string. The standard output.
+//   - err: error. Non-nil if secret-tool is missing or fails; stderr is included.
+func secretTool(ctx context.Context, stdin *strings.Reader, args ...string) (string, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", errors.New("secret-tool not found, install libsecret-tools to use the secret-service backend")
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("secret-tool %s: %w: %s", args[0], err, msg)
+		}
+		return stdout.String(), fmt.Errorf("secret-tool %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestSecretServiceBackend needs secret-tool and an unlocked Secret Service on the session bus, e.g. GNOME Keyring.
+func TestSecretServiceBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the Secret Service test in short mode")
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		t.Skip("secret-tool is not installed")
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		t.Skip("no D-Bus session bus for the Secret Service")
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ref := Ref{Backend: "secret-service", Path: "conflux-test/" + hex.EncodeToString(suffix)}
+	backend := SecretServiceBackend{}
+
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	t.Cleanup(func() { backend.Delete(ctx, ref) })
+	if got, err := backend.Get(ctx, ref); err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,207 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultVaultAddr is used when VAULT_ADDR is unset.
+	DefaultVaultAddr = "http://127.0.0.1:8200"
+	// DefaultVaultField is the KV key read when a reference has no #field.
+	DefaultVaultField = "value"
+)
+
+// VaultBackend stores secrets in a HashiCorp Vault KV version 2 engine.
+// The first path segment is the mount and the rest the secret path, e.g. secret://vault/secret/conflux#token.
+// It uses the standard VAULT_ADDR, VAULT_TOKEN (or ~/.vault-token), and VAULT_NAMESPACE variables.
+type VaultBackend struct {
+	// HTTPClient is used for requests; nil uses a client with a 30s timeout.
+	HTTPClient *http.Client
+}
+
+func init() {
+	Register("vault", &VaultBackend{})
+}
+
+// Get reads one field of the latest version of the secret.
+func (v *VaultBackend) Get(ctx context.Context, ref Ref) (string, error) {
+	data, err := v.read(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[vaultField(ref)]
+	if !ok {
+		return "", fmt.Errorf("%w: field %q", ErrNotFound, vaultField(ref))
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q is not a string", vaultField(ref))
+	}
+	return s, nil
+}
+
+// Set writes a new version of the secret with the field set, keeping its other fields.
+func (v *VaultBackend) Set(ctx context.Context, ref Ref, value string) error {
+	data, err := v.read(ctx, ref)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	data[vaultField(ref)] = value
+	return v.write(ctx, ref, data)
+}
+
+// Delete writes a new version of the secret without the field.
+func (v *VaultBackend) Delete(ctx context.Context, ref Ref) error {
+	data, err := v.read(ctx, ref)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := data[vaultField(ref)]; !ok {
+		return nil
+	}
+	delete(data, vaultField(ref))
+	return v.write(ctx, ref, data)
+}
+
+// read returns the data of the latest version of the secret.
+func (v *VaultBackend) read(ctx context.Context, ref Ref) (map[string]any, error) {
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, ref, nil, &resp); err != nil {
+		return nil, err
+	}
+	// A deleted latest version reads as null data
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref.Path)
+	}
+	return resp.Data.Data, nil
+}
+
+// write stores data as a new version of the secret.
+func (v *VaultBackend) write(ctx context.Context, ref Ref, data map[string]any) error {
+	return v.do(ctx, http.MethodPost, ref, map[string]any{"data": data}, nil)
+}
+
+// do sends a request to the KV v2 data endpoint of ref.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - method: string. HTTP method.
+//   - ref: Ref. The secret reference.
+//   - in: any. Optional JSON request body.
+//   - out: any. Optional JSON response target.
+//
+// Outputs:
+//   - err: error. Wraps ErrNotFound on 404; includes Vault's error messages otherwise.
+func (v *VaultBackend) do(ctx context.Context, method string, ref Ref, in any, out any) error {
+	mount, path, ok := strings.Cut(ref.Path, "/")
+	if !ok || path == "" {
+		return fmt.Errorf("%s: expected %s://vault/<mount>/<path>", ref, Scheme)
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return err
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = DefaultVaultAddr
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(addr, "/")+"/v1/"+mount+"/data/"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, ref.Path)
+	}
+	if resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault %s %s: %d: %s", method, req.URL.Path, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault %s %s: %d", method, req.URL.Path, resp.StatusCode)
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// vaultField returns the KV key a reference selects.
+func vaultField(ref Ref) string {
+	if ref.Field == "" {
+		return DefaultVaultField
+	}
+	return ref.Field
+}
+
+// vaultToken returns VAULT_TOKEN or the token cached by the vault CLI.
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", fmt.Errorf("no vault token, set VAULT_TOKEN or run `vault login`")
+}
+
+// isNotFound reports whether err wraps ErrNotFound.
+func isNotFound(err error) bool {
+	return err != nil && errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,185 @@
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault is a minimal KV version 2 data endpoint that keeps the latest version of each secret.
+type fakeVault struct {
+	mu      sync.Mutex
+	token   string
+	secrets map[string]map[string]any
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	case http.MethodPost:
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[r.URL.Path] = body.Data
+		w.Write([]byte(`{"data":{"version":1}}`))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestVaultBackendProtocol(t *testing.T) {
+	fake := &fakeVault{token: "test-token", secrets: map[string]map[string]any{
+		"/v1/secret/data/conflux": {"other": "kept"},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	ctx := context.Background()
+	backend := &VaultBackend{}
+	ref := Ref{Backend: "vault", Path: "secret/conflux", Field: "token"}
+
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing field error = %v, want ErrNotFound", err)
+	}
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	got, err := backend.Get(ctx, ref)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+	if fake.secrets["/v1/secret/data/conflux"]["other"] != "kept" {
+		t.Error("Set dropped the other fields of the secret")
+	}
+
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if fake.secrets["/v1/secret/data/conflux"]["other"] != "kept" {
+		t.Error("Delete dropped the other fields of the secret")
+	}
+
+	missing := Ref{Backend: "vault", Path: "secret/missing"}
+	if _, err := backend.Get(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if err := backend.Delete(ctx, missing); err != nil {
+		t.Errorf("Delete of a missing secret error = %v, want nil", err)
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	if _, err := backend.Get(ctx, ref); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Get with a wrong token error = %v, want Vault's message", err)
+	}
+}
+
+func TestVaultBackendRejectsPathWithoutMount(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "test-token")
+	if _, err := (&VaultBackend{}).Get(context.Background(), Ref{Backend: "vault", Path: "conflux"}); err == nil {
+		t.Error("Get without a mount error = nil")
+	}
+}
+
+// TestVaultBackendDevServer runs against a real Vault, e.g. `vault server -dev` with VAULT_ADDR and VAULT_TOKEN
+// exported; it uses the KV version 2 engine the dev server mounts at secret/.
+func TestVaultBackendDevServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the Vault dev server test in short mode")
+	}
+	if os.Getenv("VAULT_ADDR") == "" {
+		t.Skip("VAULT_ADDR is not set, start `vault server -dev` to run this test")
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	path := "conflux-test/" + hex.EncodeToString(suffix)
+	t.Cleanup(func() { deleteVaultMetadata(t, path) })
+
+	ctx := context.Background()
+	backend := &VaultBackend{}
+	ref := Ref{Backend: "vault", Path: "secret/" + path, Field: "token"}
+	other := Ref{Backend: "vault", Path: "secret/" + path, Field: "other"}
+
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a new secret error = %v, want ErrNotFound", err)
+	}
+	if err := backend.Set(ctx, other, "kept"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	if err := backend.Set(ctx, ref, "s3cret"); err != nil {
+		t.Fatalf("Set error = %v", err)
+	}
+	if got, err := backend.Get(ctx, ref); err != nil || got != "s3cret" {
+		t.Fatalf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+	if err := backend.Delete(ctx, ref); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if _, err := backend.Get(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if got, err := backend.Get(ctx, other); err != nil || got != "kept" {
+		t.Errorf("Get of the other field = %q, %v; want %q", got, err, "kept")
+	}
+
+	// The same reference through the package API
+	if err := Store(ctx, ref.String(), "again"); err != nil {
+		t.Fatalf("Store error = %v", err)
+	}
+	if got, err := Resolve(ctx, ref.String()); err != nil || got != "again" {
+		t.Errorf("Resolve = %q, %v; want %q", got, err, "again")
+	}
+}
+
+// deleteVaultMetadata removes every version of a test secret from the dev server.
+func deleteVaultMetadata(t *testing.T, path string) {
+	token, err := vaultToken()
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodDelete, strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")+"/v1/secret/metadata/"+path, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Logf("failed to clean up secret/%s: %v", path, err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"os"
+	"os/exec"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -11,8 +13,25 @@ import (
 	pb "github.com/veil-net/conflux/proto"
 )
 
-// ServiceImpl is the concrete implementation that runs the anchor (load config, start subprocess, gRPC client, handle signals).
+// anchorExitTimeout bounds how long stop waits for a killed anchor subprocess to exit.
+const anchorExitTimeout = 5 * time.Second
+
+// ServiceImpl is the concrete implementation that runs the anchor (load config, subprocess, gRPC client, signals).
 type ServiceImpl struct {
+	mu         sync.Mutex
+	config     *anchor.ConfluxConfig
+	running    bool
+	subprocess *exec.Cmd
+	client     pb.AnchorClient
+	status     anchor.AnchorStatus
+	// cancelSupervisor stops the supervisor before the subprocess is killed on purpose
+	cancelSupervisor context.CancelFunc
+	// failed receives the error that ends Run when the supervisor gives up on the anchor
+	failed chan error
+	// clock, launch, and exited are the time source and the anchor subprocess of the supervisor; tests replace them
+	clock  clock
+	launch func(ctx context.Context, options anchor.ReadyOptions) (*exec.Cmd, pb.AnchorClient, error)
+	exited func(subprocess *exec.Cmd) <-chan struct{}
 }
 
 // NewServiceImpl returns a new ServiceImpl.
@@ -22,79 +41,249 @@ type ServiceImpl struct {
 // Outputs:
 //   - *ServiceImpl. A new ServiceImpl.
 func NewServiceImpl() *ServiceImpl {
-	return &ServiceImpl{}
+	return &ServiceImpl{
+		failed: make(chan error, 1),
+		clock:  realClock{},
+		launch: anchor.NewReadyAnchor,
+		exited: anchor.Exited,
+	}
 }
 
-// Run runs the anchor in the foreground until interrupt (loads config, starts subprocess and gRPC client, handles signals).
+// Run runs the anchor in the foreground until interrupt (starts the service, reloads config changes, waits for signals, then stops it).
 //
 // Inputs:
 //   - s: *ServiceImpl. The implementation; uses config from the default config file.
 //
-// Outputs: none. Does not return; runs until process interrupt (SIGINT/SIGTERM).
-func (s *ServiceImpl) Run() {
+// Outputs:
+//   - err: error. Non-nil on start failure, when the anchor crash loops, or when it connects to a veil the conflux is
+//     not pinned to, so the service manager sees the failure; a veil mismatch carries exitVeilMismatch, which the
+//     service manager does not restart. Nil after process interrupt (SIGINT/SIGTERM).
+func (s *ServiceImpl) Run() error {
+	if err := s.Start(); err != nil {
+		return withExitCode(err)
+	}
+	defer s.Stop()
+
+	// Apply edits to the config files without a restart where possible
+	stopWatcher := s.startConfigWatcher()
+	defer stopWatcher()
+
+	return withExitCode(s.wait())
+}
+
+// RunDebug runs the anchor in the foreground with config, started and supervised as the service would, but without
+// installing a service or watching the config files; up --debug and register --debug use it.
+//
+// Inputs:
+//   - config: *anchor.ConfluxConfig. The conflux config; it need not be saved.
+//
+// Outputs:
+//   - err: error. Non-nil if the identity was exported from this host, on start failure, or when the anchor crash
+//     loops; nil after process interrupt (SIGINT/SIGTERM).
+func RunDebug(config *anchor.ConfluxConfig) error {
+	if err := anchor.CheckIdentity(config); err != nil {
+		Logger.Sugar().Errorf("refusing to start: %v", err)
+		return err
+	}
+	s := NewServiceImpl()
+	s.mu.Lock()
+	err := s.startWith(config)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer s.Stop()
+	return s.wait()
+}
+
+// wait blocks until SIGINT/SIGTERM, or until the supervisor gives up on the anchor.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - err: error. The supervisor's failure; nil after an interrupt.
+func (s *ServiceImpl) wait() error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	select {
+	case <-interrupt:
+		return nil
+	case err := <-s.failed:
+		return err
+	}
+}
+
+// Failed returns a channel that receives ErrCrashLoop or anchor.ErrVeilMismatch when the supervisor gives up on the
+// anchor subprocess.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - <-chan error. The failure.
+func (s *ServiceImpl) Failed() <-chan error {
+	return s.failed
+}
+
+// Start loads config, starts the anchor subprocess, and starts the anchor.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - err: error. Non-nil if any step fails; the failure is already logged and partial state is cleaned up.
+func (s *ServiceImpl) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.start()
+}
 
-	// Load the configuration
-	config, err := anchor.LoadConfig()
+// start implements Start; the caller holds s.mu.
+func (s *ServiceImpl) start() error {
+	// Load the configuration, migrating an older file once
+	config, err := anchor.LoadMigratedConfig()
 	if err != nil {
 		Logger.Sugar().Errorf("failed to load configuration: %v", err)
-		return
+		return err
+	}
+	if err := anchor.Validate(config); err != nil {
+		Logger.Sugar().Errorf("invalid configuration: %v", err)
+		return err
+	}
+	if err := anchor.CheckIdentity(config); err != nil {
+		Logger.Sugar().Errorf("refusing to start: %v", err)
+		return err
 	}
+	return s.startWith(config)
+}
+
+// startWith starts the anchor subprocess, starts the anchor with config, and supervises it; the caller holds s.mu and
+// has validated config.
+func (s *ServiceImpl) startWith(config *anchor.ConfluxConfig) error {
+	s.config = config
 
-	// Initialize the anchor plugin
-	subprocess, err := anchor.NewAnchor()
+	// Start the anchor plugin and wait for its gRPC server
+	subprocess, client, err := s.launch(context.Background(), anchor.DefaultReadyOptions())
 	if err != nil {
-		Logger.Sugar().Errorf("failed to initialize anchor subprocess: %v", err)
-		return
+		Logger.Sugar().Errorf("failed to start anchor subprocess: %v", err)
+		return err
 	}
-	defer subprocess.Process.Kill()
+	s.subprocess = subprocess
+	s.client = client
 
-	// Wait for the subprocess to start
-	time.Sleep(1 * time.Second)
+	// Start the anchor, which logs in to Guardian with the conflux token
+	if err := s.startAnchor(context.Background()); err != nil {
+		s.stop()
+		return err
+	}
+	s.running = true
+
+	// Restart the anchor subprocess if it exits
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelSupervisor = cancel
+	s.status = anchor.AnchorStatus{PID: subprocess.Process.Pid, StartedAt: s.clock.Now(), Restarts: s.status.Restarts}
+	s.saveStatus()
+	go s.supervise(ctx, subprocess)
+	return nil
+}
+
+// Stop kills the anchor subprocess. It does not log the conflux out of Guardian: for a rift conflux, the session
+// logout also deletes the conflux.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs: none.
+func (s *ServiceImpl) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+}
+
+// restart stops and starts the conflux under one hold of s.mu, so a concurrent Stop cannot run in between.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - err: error. Non-nil if the start fails; the failure is already logged.
+func (s *ServiceImpl) restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	return s.start()
+}
 
-	// Create a gRPC client connection
-	anchor, err := anchor.NewAnchorClient()
+// stop releases the subprocess; the caller holds s.mu.
+func (s *ServiceImpl) stop() {
+	if s.cancelSupervisor != nil {
+		s.cancelSupervisor()
+		s.cancelSupervisor = nil
+	}
+	if s.subprocess != nil {
+		s.subprocess.Process.Kill()
+		// Let the port go before a restart starts the next anchor
+		select {
+		case <-s.exited(s.subprocess):
+		case <-time.After(anchorExitTimeout):
+			Logger.Sugar().Warnf("anchor subprocess did not exit within %s", anchorExitTimeout)
+		}
+		s.subprocess = nil
+	}
+	s.client = nil
+	s.running = false
+}
+
+// startAnchor issues StartAnchor with the conflux token, enforces the region/veil pin, and replays taints; the caller
+// holds s.mu. StartAnchor only accepts the long-lived conflux token, so the anchor keeps it for as long as it runs.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs:
+//   - err: error. Non-nil if the anchor cannot be started or configured; the failure is already logged.
+func (s *ServiceImpl) startAnchor(ctx context.Context) error {
+	config := s.config
+
+	tracer, err := anchor.TracerRequest(ctx, config.Tracer)
 	if err != nil {
-		Logger.Sugar().Errorf("failed to create anchor gRPC client: %v", err)
-		return
+		Logger.Sugar().Errorf("failed to resolve tracer config: %v", err)
+		return err
 	}
 
 	// Start the anchor
-	_, err = anchor.StartAnchor(context.Background(), &pb.StartAnchorRequest{
+	_, err = s.client.StartAnchor(ctx, &pb.StartAnchorRequest{
 		GuardianUrl: config.Guardian,
 		AnchorToken: config.Token,
 		Ip:          config.IP,
+		Rift:        config.Rift,
 		Portal:      !config.Rift,
-		Tracer: &pb.TracerConfig{
-			Enabled:  config.Tracer.Enabled,
-			Endpoint: config.Tracer.Endpoint,
-			UseTls:   config.Tracer.UseTLS,
-			Insecure: config.Tracer.Insecure,
-			Ca:       config.Tracer.CAFile,
-			Cert:     config.Tracer.CertFile,
-			Key:      config.Tracer.KeyFile,
-		},
+		Tracer:      tracer,
 	})
 	if err != nil {
 		Logger.Sugar().Errorf("failed to start anchor: %v", err)
-		return
+		return err
+	}
+
+	// Enforce the region/veil pin
+	err = anchor.CheckVeil(ctx, s.client, config)
+	if err != nil {
+		Logger.Sugar().Errorf("failed to verify veil: %v", err)
+		return err
 	}
 
 	// Add taints
 	for _, taint := range config.Taints {
-		_, err = anchor.AddTaint(context.Background(), &pb.AddTaintRequest{
+		_, err = s.client.AddTaint(ctx, &pb.AddTaintRequest{
 			Taint: taint,
 		})
 		if err != nil {
 			Logger.Sugar().Errorf("failed to add taint: %v", err)
-			return
+			return err
 		}
 	}
-
-	// Wait for interrupt signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	// Wait for interrupt signal
-	<-interrupt
-
+	return nil
 }
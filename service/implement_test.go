@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+)
+
+func TestRunDebugRefusesExportedIdentity(t *testing.T) {
+	anchor.SetConfigDir(t.TempDir())
+	t.Cleanup(func() { anchor.SetConfigDir("") })
+	config := &anchor.ConfluxConfig{ConfluxID: "conflux-1", Token: "token", Guardian: "https://guardian.test"}
+	if err := anchor.MarkIdentityExported(&anchor.IdentityBundle{Config: config, ExportedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The check runs before the anchor subprocess is started
+	if err := RunDebug(config); !errors.Is(err, anchor.ErrIdentityExported) {
+		t.Errorf("RunDebug = %v, want ErrIdentityExported", err)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+	pb "github.com/veil-net/conflux/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// configPollInterval is how often the config files are checked for changes.
+const configPollInterval = 2 * time.Second
+
+// loginConfigKeys change how the anchor logs in to Guardian, so the anchor subprocess is replaced by a new one.
+var loginConfigKeys = []string{"conflux_id", "conflux_token", "guardian", "rift", "portal"}
+
+// anchorConfigKeys are passed to StartAnchor, so the anchor is stopped and started again in the same subprocess.
+var anchorConfigKeys = []string{"ip", "tracer."}
+
+// veilConfigKeys are enforced by CheckVeil, which runs against the live anchor.
+var veilConfigKeys = []string{"region", "veil"}
+
+// watchConfig polls the config files and reloads the config once a change has settled, until ctx is done.
+//
+// Inputs:
+//   - ctx: context.Context. Stops the watcher.
+//
+// Outputs: none. Reload failures are logged and the previous config stays in effect.
+func (s *ServiceImpl) watchConfig(ctx context.Context) {
+	files, err := anchor.ConfigFiles()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to watch config: %v", err)
+		return
+	}
+	applied := fingerprint(files)
+	var pending []byte
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := fingerprint(files)
+		if bytes.Equal(current, applied) {
+			pending = nil
+			continue
+		}
+		// Wait one more interval so a file being rewritten in several steps is read once it is complete
+		if !bytes.Equal(current, pending) {
+			pending = current
+			continue
+		}
+		applied = current
+		pending = nil
+		s.reload(ctx)
+	}
+}
+
+// ReloadEffect describes what a running conflux service does when the given config keys change, for commands that
+// edit the config.
+//
+// Inputs:
+//   - keys: []string. The changed keys, as returned by anchor.DiffConfig.
+//
+// Outputs:
+//   - string. A sentence for the strongest effect, or "" if keys is empty.
+func ReloadEffect(keys []string) string {
+	switch {
+	case len(keys) == 0:
+		return ""
+	case slices.ContainsFunc(keys, isKey(loginConfigKeys)):
+		return "a running conflux service restarts the anchor subprocess within a few seconds to log in with it"
+	case slices.ContainsFunc(keys, isKey(anchorConfigKeys)):
+		return "a running conflux service restarts the anchor within a few seconds to apply it"
+	case slices.ContainsFunc(keys, func(key string) bool { return key == "taints" || isKey(veilConfigKeys)(key) }):
+		return "a running conflux service applies it live within a few seconds"
+	default:
+		return "it does not affect a running conflux"
+	}
+}
+
+// startConfigWatcher runs watchConfig in the background.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - func(). Stops the watcher and waits for a reload in progress, so it cannot start the conflux after a Stop.
+func (s *ServiceImpl) startConfigWatcher() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.watchConfig(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// fingerprint hashes the content of files; missing files hash as empty.
+func fingerprint(files []string) []byte {
+	hash := sha256.New()
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		hash.Write([]byte(file))
+		hash.Write(content)
+	}
+	return hash.Sum(nil)
+}
+
+// reload loads and validates the config and applies what changed: taints and the region/veil pin live, and a
+// restart of the anchor or its subprocess only for the fields that need it.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//
+// Outputs: none. Every applied change and failure is logged.
+func (s *ServiceImpl) reload(ctx context.Context) {
+	config, err := anchor.LoadConfig()
+	if err == nil {
+		err = anchor.Validate(config)
+	}
+	if err != nil {
+		Logger.Sugar().Errorf("config changed but cannot be applied, keeping the running config: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	previous := s.config
+	// The supervisor clears the client while it restarts the anchor, so it does not tell whether the conflux runs
+	running := s.running
+	s.mu.Unlock()
+
+	if !running {
+		Logger.Sugar().Infof("config changed, starting the conflux")
+		s.Start()
+		return
+	}
+	changed := anchor.DiffConfig(previous, config)
+	if len(changed) == 0 {
+		return
+	}
+	for _, key := range changed {
+		logConfigChange(key, previous, config)
+	}
+
+	if slices.ContainsFunc(changed, isKey(loginConfigKeys)) {
+		Logger.Sugar().Infof("restarting the anchor subprocess to apply %s", strings.Join(changed, ", "))
+		s.restart()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+	if s.client == nil {
+		// The supervisor is restarting the anchor, which then starts with this config
+		return
+	}
+
+	if slices.ContainsFunc(changed, isKey(anchorConfigKeys)) {
+		// startAnchor also re-checks the veil pin and replays the taints
+		Logger.Sugar().Infof("restarting the anchor to apply %s", strings.Join(changed, ", "))
+		if _, err := s.client.StopAnchor(ctx, &emptypb.Empty{}); err != nil {
+			Logger.Sugar().Warnf("failed to stop anchor: %v", err)
+		}
+		if err := s.startAnchor(ctx); err != nil {
+			Logger.Sugar().Errorf("failed to restart anchor after config change: %v", err)
+		}
+		return
+	}
+
+	for _, key := range changed {
+		if key != "taints" && !isKey(veilConfigKeys)(key) {
+			Logger.Sugar().Infof("%s does not affect the running conflux, nothing to apply", key)
+		}
+	}
+	if slices.Contains(changed, "taints") {
+		s.applyTaints(ctx, previous.Taints, config.Taints)
+	}
+	if slices.ContainsFunc(changed, isKey(veilConfigKeys)) {
+		if err := anchor.CheckVeil(ctx, s.client, config); err != nil {
+			Logger.Sugar().Errorf("failed to verify veil after config change: %v", err)
+		} else {
+			Logger.Sugar().Infof("applied the region/veil pin")
+		}
+	}
+}
+
+// applyTaints adds and removes taints on the running anchor; the caller holds s.mu.
+//
+// Inputs:
+//   - ctx: context.Context. Request context.
+//   - previous: []string. The taints the anchor has.
+//   - current: []string. The taints it should have.
+//
+// Outputs: none. Each change and failure is logged.
+func (s *ServiceImpl) applyTaints(ctx context.Context, previous []string, current []string) {
+	for _, taint := range current {
+		if slices.Contains(previous, taint) {
+			continue
+		}
+		if _, err := s.client.AddTaint(ctx, &pb.AddTaintRequest{Taint: taint}); err != nil {
+			Logger.Sugar().Warnf("failed to add taint %q: %v", taint, err)
+			continue
+		}
+		Logger.Sugar().Infof("added taint %q", taint)
+	}
+	for _, taint := range previous {
+		if slices.Contains(current, taint) {
+			continue
+		}
+		if _, err := s.client.RemoveTaint(ctx, &pb.RemoveTaintRequest{Taint: taint}); err != nil {
+			Logger.Sugar().Warnf("failed to remove taint %q: %v", taint, err)
+			continue
+		}
+		Logger.Sugar().Infof("removed taint %q", taint)
+	}
+}
+
+// isKey returns a matcher for config keys equal to one of keys, or under one ending in a dot.
+func isKey(keys []string) func(string) bool {
+	return func(key string) bool {
+		for _, k := range keys {
+			if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// logConfigChange logs the old and new value of a changed key, redacting secrets.
+func logConfigChange(key string, previous *anchor.ConfluxConfig, current *anchor.ConfluxConfig) {
+	if anchor.IsSecretConfigKey(key) {
+		Logger.Sugar().Infof("config changed: %s", key)
+		return
+	}
+	before, _ := anchor.GetConfigValue(previous, key)
+	after, _ := anchor.GetConfigValue(current, key)
+	Logger.Sugar().Infof("config changed: %s: %v -> %v", key, before, after)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veil-net/conflux/anchor"
+)
+
+func TestIsKey(t *testing.T) {
+	tests := []struct {
+		keys []string
+		key  string
+		want bool
+	}{
+		{[]string{"ip", "tracer."}, "ip", true},
+		{[]string{"ip", "tracer."}, "tracer.endpoint", true},
+		{[]string{"ip", "tracer."}, "tracer", false},
+		{[]string{"ip", "tracer."}, "ipv6", false},
+		{[]string{"region", "veil"}, "veil", true},
+		{nil, "veil", false},
+	}
+	for _, tt := range tests {
+		if got := isKey(tt.keys)(tt.key); got != tt.want {
+			t.Errorf("isKey(%q)(%q) = %t, want %t", tt.keys, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestReloadEffect(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(c *anchor.ConfluxConfig)
+		// want is a snippet of the effect; "" means no change
+		want string
+	}{
+		{"nothing", func(c *anchor.ConfluxConfig) {}, ""},
+		{"token", func(c *anchor.ConfluxConfig) { c.Token = "other-token" }, "restarts the anchor subprocess"},
+		{"guardian", func(c *anchor.ConfluxConfig) { c.Guardian = "https://other.test" }, "restarts the anchor subprocess"},
+		{"rift", func(c *anchor.ConfluxConfig) { c.Rift = !c.Rift }, "restarts the anchor subprocess"},
+		{"ip", func(c *anchor.ConfluxConfig) { c.IP = "10.0.0.9" }, "restarts the anchor within"},
+		{"tracer", func(c *anchor.ConfluxConfig) { c.Tracer = &anchor.TracerConfig{Enabled: true, Endpoint: "otel:4317"} },
+			"restarts the anchor within"},
+		{"taints", func(c *anchor.ConfluxConfig) { c.Taints = append(c.Taints, "dev") }, "applies it live"},
+		{"taint order", func(c *anchor.ConfluxConfig) { c.Taints = []string{"b", "a"} }, ""},
+		{"veil", func(c *anchor.ConfluxConfig) { c.Veil = "veil-1" }, "applies it live"},
+		{"region", func(c *anchor.ConfluxConfig) { c.Region = "eu" }, "applies it live"},
+		{"portal", func(c *anchor.ConfluxConfig) { c.Portal = true }, "restarts the anchor subprocess"},
+		{"token reference only", func(c *anchor.ConfluxConfig) { c.TokenRef = "file:/run/token" }, "does not affect"},
+		{"login wins over live", func(c *anchor.ConfluxConfig) { c.Token = "other-token"; c.Taints = []string{"dev"} },
+			"restarts the anchor subprocess"},
+		{"anchor wins over live", func(c *anchor.ConfluxConfig) { c.IP = "10.0.0.9"; c.Veil = "veil-1" },
+			"restarts the anchor within"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := &anchor.ConfluxConfig{ConfluxID: "conflux-1", Token: "token", Guardian: "https://guardian.test", Taints: []string{"a", "b"}}
+			current := *previous
+			current.Taints = append([]string(nil), previous.Taints...)
+			tt.edit(&current)
+
+			got := ReloadEffect(anchor.DiffConfig(previous, &current))
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("ReloadEffect = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,16 +2,50 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
+	"github.com/veil-net/conflux/anchor"
 	"github.com/veil-net/conflux/logger"
 )
 
 // Logger re-exports the global logger for the service package.
 var Logger = logger.Logger
 
+// ErrOtherInstanceInstalled is returned by Install while another instance's service is installed.
+var ErrOtherInstanceInstalled = errors.New("another conflux instance is installed as a service")
+
+// exitError is a service error that carries a specific process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for the error.
+func (e *exitError) ExitCode() int { return e.code }
+
+// withExitCode gives a veil mismatch the exitVeilMismatch status, so the service manager does not restart the
+// conflux into the same veil; other errors are returned unchanged.
+//
+// Inputs:
+//   - err: error. A start or restart failure.
+//
+// Outputs:
+//   - error. err, wrapped with its exit code if it is an anchor.ErrVeilMismatch.
+func withExitCode(err error) error {
+	if errors.Is(err, anchor.ErrVeilMismatch) {
+		return &exitError{code: exitVeilMismatch, err: err}
+	}
+	return err
+}
+
 // Service is the interface for running and managing the conflux service (Run, Install, Start, Stop, Remove, Status).
 type Service interface {
 	Run() error
@@ -47,4 +81,55 @@ func ExecuteCmd(cmd ...string) error {
 		return fmt.Errorf("failed to execute command %s, error: %w", cmd, err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// checkOtherInstances refuses to install the selected instance's service while another instance's service is installed.
+// The embedded anchor has no setting for its gRPC port, so every instance listens on anchor.AnchorAddr; two services
+// restarting each other's failed anchor would fight over the port forever.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - err: error. ErrOtherInstanceInstalled naming the installed instance, or non-nil if the installed services cannot be listed.
+func checkOtherInstances() error {
+	installed, err := installedInstances()
+	if err != nil {
+		Logger.Sugar().Errorf("failed to list installed conflux services: %v", err)
+		return err
+	}
+	for _, name := range installed {
+		if name == anchor.Instance() {
+			continue
+		}
+		which, flag := "the default instance", ""
+		if name != "" {
+			which, flag = "instance "+name, " --instance "+name
+		}
+		err := fmt.Errorf("%w: %s; instances share the anchor port %s, so remove it first with `conflux%s remove`",
+			ErrOtherInstanceInstalled, which, anchor.AnchorAddr(), flag)
+		Logger.Sugar().Errorf("refusing to install the service: %v", err)
+		return err
+	}
+	return nil
+}
+
+// instanceOf returns the instance a service name belongs to.
+//
+// Inputs:
+//   - name: string. The service name without any file extension, e.g. "veilnet-lab".
+//   - base: string. The default instance's service name, e.g. "veilnet".
+//   - sep: string. The separator between base and an instance name, e.g. "-".
+//
+// Outputs:
+//   - instance: string. The instance name, or "" for the default instance.
+//   - ok: bool. False if name is not a conflux service.
+func instanceOf(name, base, sep string) (instance string, ok bool) {
+	if name == base {
+		return "", true
+	}
+	instance, ok = strings.CutPrefix(name, base+sep)
+	if !ok || instance == "" || anchor.ValidateInstance(instance) != nil {
+		return "", false
+	}
+	return instance, true
+}
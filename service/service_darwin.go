@@ -7,7 +7,10 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"github.com/veil-net/conflux/anchor"
 )
 
 // LaunchDaemonPlistTemplate is the LaunchDaemon plist template for the conflux service.
@@ -16,23 +19,77 @@ const LaunchDaemonPlistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 <plist version="1.0">
 <dict>
 	<key>Label</key>
-	<string>org.veilnet.conflux</string>
+	<string>{{.Label}}</string>
 	<key>ProgramArguments</key>
 	<array>
 		<string>{{.ExecPath}}</string>
 	</array>
+	{{- if or .ConfigDir .Instance}}
+	<key>EnvironmentVariables</key>
+	<dict>
+		{{- if .ConfigDir}}
+		<key>VEILNET_CONFIG_DIR</key>
+		<string>{{.ConfigDir}}</string>
+		{{- end}}
+		{{- if .Instance}}
+		<key>VEILNET_CONFLUX_INSTANCE</key>
+		<string>{{.Instance}}</string>
+		{{- end}}
+	</dict>
+	{{- end}}
 	<key>RunAtLoad</key>
 	<true/>
 	<key>KeepAlive</key>
-	<true/>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
 	<key>StandardOutPath</key>
-	<string>/var/log/veilnet-conflux.log</string>
+	<string>/var/log/{{.LogName}}.log</string>
 	<key>StandardErrorPath</key>
-	<string>/var/log/veilnet-conflux.error.log</string>
+	<string>/var/log/{{.LogName}}.error.log</string>
 </dict>
 </plist>
 `
 
+// exitVeilMismatch is the exit status on a veil mismatch. launchd cannot exclude a failure status from KeepAlive, so a
+// mismatch exits successfully and the plist only keeps the service alive after an unsuccessful exit.
+const exitVeilMismatch = 0
+
+// launchdLabel returns the LaunchDaemon label of the selected instance: org.veilnet.conflux, or org.veilnet.conflux.<instance>.
+func launchdLabel() string {
+	if instance := anchor.Instance(); instance != "" {
+		return "org.veilnet.conflux." + instance
+	}
+	return "org.veilnet.conflux"
+}
+
+// plistPath returns the LaunchDaemon plist file of the selected instance.
+func plistPath() string {
+	return filepath.Join("/Library/LaunchDaemons", launchdLabel()+".plist")
+}
+
+// installedInstances returns the instances whose LaunchDaemon plist is installed.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - instances: []string. The instance names, "" for the default instance.
+//   - err: error. Non-nil if the LaunchDaemons directory cannot be listed.
+func installedInstances() ([]string, error) {
+	plists, err := filepath.Glob("/Library/LaunchDaemons/org.veilnet.conflux*.plist")
+	if err != nil {
+		return nil, err
+	}
+	var instances []string
+	for _, plist := range plists {
+		if instance, ok := instanceOf(strings.TrimSuffix(filepath.Base(plist), ".plist"), "org.veilnet.conflux", "."); ok {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
+
 // service is the Darwin implementation holding the ServiceImpl.
 type service struct {
 	serviceImpl *ServiceImpl
@@ -56,9 +113,7 @@ func newService() *service {
 func (s *service) Run() error {
 
 	// Run the API
-	s.serviceImpl.Run()
-
-	return nil
+	return s.serviceImpl.Run()
 }
 
 // Install installs and starts the conflux service via LaunchDaemon (launchctl bootstrap).
@@ -67,8 +122,14 @@ func (s *service) Run() error {
 //   - s: *service. The Darwin service.
 //
 // Outputs:
-//   - err: error. Non-nil if the template, file write, or system command fails.
+//   - err: error. ErrOtherInstanceInstalled if another instance's plist is installed; non-nil if the template, file
+//     write, or system command fails.
 func (s *service) Install() error {
+	// Only one instance can hold the anchor port
+	if err := checkOtherInstances(); err != nil {
+		return err
+	}
+
 	// Get current executable path
 	exePath, err := os.Executable()
 	if err != nil {
@@ -90,13 +151,30 @@ func (s *service) Install() error {
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, struct{ ExecPath string }{ExecPath: realPath}); err != nil {
+	logName := "veilnet-conflux"
+	if instance := anchor.Instance(); instance != "" {
+		logName += "-" + instance
+	}
+	data := struct {
+		ExecPath  string
+		ConfigDir string
+		Instance  string
+		Label     string
+		LogName   string
+	}{
+		ExecPath:  realPath,
+		ConfigDir: anchor.ConfigDirOverride(),
+		Instance:  anchor.Instance(),
+		Label:     launchdLabel(),
+		LogName:   logName,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
 		Logger.Sugar().Errorf("failed to execute launchdaemon template: %v", err)
 		return err
 	}
 
 	// Write plist file
-	plistFile := "/Library/LaunchDaemons/org.veilnet.conflux.plist"
+	plistFile := plistPath()
 	if err := os.WriteFile(plistFile, buf.Bytes(), 0644); err != nil {
 		Logger.Sugar().Errorf("failed to write launchdaemon plist file: %v", err)
 		return err
@@ -120,7 +198,7 @@ func (s *service) Install() error {
 // Outputs:
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Start() error {
-	plistFile := "/Library/LaunchDaemons/org.veilnet.conflux.plist"
+	plistFile := plistPath()
 	err := ExecuteCmd("launchctl", "bootstrap", "system", plistFile)
 	if err != nil {
 		return err
@@ -137,7 +215,7 @@ func (s *service) Start() error {
 // Outputs:
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Stop() error {
-	plistFile := "/Library/LaunchDaemons/org.veilnet.conflux.plist"
+	plistFile := plistPath()
 	err := ExecuteCmd("launchctl", "bootout", "system", plistFile)
 	if err != nil {
 		return err
@@ -154,7 +232,7 @@ func (s *service) Stop() error {
 // Outputs:
 //   - err: error. Non-nil if a step fails.
 func (s *service) Remove() error {
-	plistFile := "/Library/LaunchDaemons/org.veilnet.conflux.plist"
+	plistFile := plistPath()
 	err := ExecuteCmd("launchctl", "bootout", "system", plistFile)
 	if err != nil {
 		return err
@@ -177,7 +255,7 @@ func (s *service) Remove() error {
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Status() error {
 	// Check if the service is running
-	err := ExecuteCmd("launchctl", "list", "org.veilnet.conflux")
+	err := ExecuteCmd("launchctl", "list", launchdLabel())
 	if err != nil {
 		return err
 	}
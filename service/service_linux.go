@@ -7,12 +7,16 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"github.com/veil-net/conflux/anchor"
 )
 
-// SystemdUnitTemplate is the systemd unit file template for the conflux service.
+// SystemdUnitTemplate is the systemd unit file template for the conflux service. The service runs as root, which the
+// anchor needs to manage its network interface and which owns the system config and state directories.
 const SystemdUnitTemplate = `[Unit]
-Description=VeilNet Service
+Description=VeilNet Service{{if .Instance}} ({{.Instance}}){{end}}
 After=network.target
 Wants=network.target
 Before=multi-user.target
@@ -20,8 +24,15 @@ Before=multi-user.target
 [Service]
 Type=simple
 ExecStart={{.ExecPath}}
+{{- if .ConfigDir}}
+Environment="VEILNET_CONFIG_DIR={{.ConfigDir}}"
+{{- end}}
+{{- if .Instance}}
+Environment="VEILNET_CONFLUX_INSTANCE={{.Instance}}"
+{{- end}}
 Restart=always
 RestartSec=5
+RestartPreventExitStatus={{.VeilMismatchStatus}}
 User=root
 Group=root
 TimeoutStopSec=30
@@ -32,6 +43,41 @@ KillSignal=SIGTERM
 WantedBy=multi-user.target
 `
 
+// exitVeilMismatch is the exit status on a veil mismatch; the unit's RestartPreventExitStatus keeps the service down.
+const exitVeilMismatch = 78
+
+// systemdUnitDir is where the unit files are installed.
+var systemdUnitDir = "/etc/systemd/system"
+
+// unitName returns the systemd unit of the selected instance: veilnet.service, or veilnet-<instance>.service.
+func unitName() string {
+	if instance := anchor.Instance(); instance != "" {
+		return "veilnet-" + instance + ".service"
+	}
+	return "veilnet.service"
+}
+
+// installedInstances returns the instances whose unit file is installed.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - instances: []string. The instance names, "" for the default instance.
+//   - err: error. Non-nil if the unit directory cannot be listed.
+func installedInstances() ([]string, error) {
+	units, err := filepath.Glob(filepath.Join(systemdUnitDir, "veilnet*.service"))
+	if err != nil {
+		return nil, err
+	}
+	var instances []string
+	for _, unit := range units {
+		if instance, ok := instanceOf(strings.TrimSuffix(filepath.Base(unit), ".service"), "veilnet", "-"); ok {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
+
 // service is the Linux implementation holding the ServiceImpl.
 type service struct {
 	serviceImpl *ServiceImpl
@@ -55,9 +101,7 @@ func newService() *service {
 func (s *service) Run() error {
 
 	// Run the API
-	s.serviceImpl.Run()
-
-	return nil
+	return s.serviceImpl.Run()
 }
 
 // Install installs and starts the conflux service via systemd.
@@ -66,8 +110,14 @@ func (s *service) Run() error {
 //   - s: *service. The Linux service.
 //
 // Outputs:
-//   - err: error. Non-nil if the template, file write, or system command fails.
+//   - err: error. ErrOtherInstanceInstalled if another instance's unit is installed; non-nil if the template, file
+//     write, or system command fails.
 func (s *service) Install() error {
+	// Only one instance can hold the anchor port
+	if err := checkOtherInstances(); err != nil {
+		return err
+	}
+
 	// Get current executable path
 	exePath, err := os.Executable()
 	if err != nil {
@@ -89,13 +139,24 @@ func (s *service) Install() error {
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, struct{ ExecPath string }{ExecPath: realPath}); err != nil {
+	data := struct {
+		ExecPath           string
+		ConfigDir          string
+		Instance           string
+		VeilMismatchStatus int
+	}{
+		ExecPath:           realPath,
+		ConfigDir:          anchor.ConfigDirOverride(),
+		Instance:           anchor.Instance(),
+		VeilMismatchStatus: exitVeilMismatch,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
 		Logger.Sugar().Errorf("failed to execute systemd template: %v", err)
 		return err
 	}
 
 	// Write unit file
-	unitFile := "/etc/systemd/system/veilnet.service"
+	unitFile := filepath.Join(systemdUnitDir, unitName())
 	if err := os.WriteFile(unitFile, buf.Bytes(), 0644); err != nil {
 		Logger.Sugar().Errorf("failed to write systemd unit file: %v", err)
 		return err
@@ -107,12 +168,12 @@ func (s *service) Install() error {
 		return err
 	}
 
-	err = ExecuteCmd("systemctl", "enable", "veilnet.service")
+	err = ExecuteCmd("systemctl", "enable", unitName())
 	if err != nil {
 		return err
 	}
 
-	err = ExecuteCmd("systemctl", "start", "veilnet.service")
+	err = ExecuteCmd("systemctl", "start", unitName())
 	if err != nil {
 		return err
 	}
@@ -129,7 +190,7 @@ func (s *service) Install() error {
 // Outputs:
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Start() error {
-	err := ExecuteCmd("systemctl", "start", "veilnet")
+	err := ExecuteCmd("systemctl", "start", unitName())
 	if err != nil {
 		return err
 	}
@@ -145,7 +206,7 @@ func (s *service) Start() error {
 // Outputs:
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Stop() error {
-	err := ExecuteCmd("systemctl", "stop", "veilnet")
+	err := ExecuteCmd("systemctl", "stop", unitName())
 	if err != nil {
 		return err
 	}
@@ -161,17 +222,17 @@ func (s *service) Stop() error {
 // Outputs:
 //   - err: error. Non-nil if a step fails.
 func (s *service) Remove() error {
-	err := ExecuteCmd("systemctl", "stop", "veilnet")
+	err := ExecuteCmd("systemctl", "stop", unitName())
 	if err != nil {
 		return err
 	}
 
-	err = ExecuteCmd("systemctl", "disable", "veilnet")
+	err = ExecuteCmd("systemctl", "disable", unitName())
 	if err != nil {
 		return err
 	}
 
-	unitFile := "/etc/systemd/system/veilnet.service"
+	unitFile := filepath.Join(systemdUnitDir, unitName())
 	err = os.Remove(unitFile)
 	if err != nil {
 		Logger.Sugar().Errorf("Failed to remove unit file: %v", err)
@@ -196,7 +257,7 @@ func (s *service) Remove() error {
 //   - err: error. Non-nil if the system command fails.
 func (s *service) Status() error {
 	// Check if the service is running
-	err := ExecuteCmd("systemctl", "status", "veilnet")
+	err := ExecuteCmd("systemctl", "status", unitName())
 	if err != nil {
 		return err
 	}
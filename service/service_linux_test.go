@@ -0,0 +1,70 @@
+//go:build linux
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/veil-net/conflux/anchor"
+)
+
+func TestInstalledInstances(t *testing.T) {
+	dir := t.TempDir()
+	previous := systemdUnitDir
+	systemdUnitDir = dir
+	t.Cleanup(func() { systemdUnitDir = previous })
+	for _, unit := range []string{"veilnet.service", "veilnet-lab.service", "veilnet-Bad_Name.service", "veilnetd.service", "other.service"} {
+		if err := os.WriteFile(filepath.Join(dir, unit), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instances, err := installedInstances()
+	if err != nil {
+		t.Fatalf("installedInstances: %v", err)
+	}
+	slices.Sort(instances)
+	if want := []string{"", "lab"}; !slices.Equal(instances, want) {
+		t.Errorf("installedInstances = %q, want %q", instances, want)
+	}
+}
+
+func TestCheckOtherInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		instance  string
+		refused   bool
+	}{
+		{"nothing installed", nil, "lab", false},
+		{"reinstall the same instance", []string{"veilnet-lab.service"}, "lab", false},
+		{"default instance installed", []string{"veilnet.service"}, "lab", true},
+		{"named instance installed", []string{"veilnet-lab.service"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			previous := systemdUnitDir
+			systemdUnitDir = dir
+			t.Cleanup(func() { systemdUnitDir = previous })
+			for _, unit := range tt.installed {
+				if err := os.WriteFile(filepath.Join(dir, unit), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := anchor.SetInstance(tt.instance); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { anchor.SetInstance("") })
+
+			err := checkOtherInstances()
+			if refused := errors.Is(err, ErrOtherInstanceInstalled); refused != tt.refused {
+				t.Errorf("checkOtherInstances = %v, want refused %t", err, tt.refused)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/veil-net/conflux/anchor"
+)
+
+func TestWithExitCode(t *testing.T) {
+	var coder interface{ ExitCode() int }
+
+	mismatch := fmt.Errorf("%w: connected to veil a, but the conflux is pinned to veil b", anchor.ErrVeilMismatch)
+	err := withExitCode(mismatch)
+	if !errors.As(err, &coder) || coder.ExitCode() != exitVeilMismatch {
+		t.Errorf("withExitCode(veil mismatch) = %v, want exit code %d", err, exitVeilMismatch)
+	}
+	if !errors.Is(err, anchor.ErrVeilMismatch) {
+		t.Errorf("withExitCode(veil mismatch) = %v, want it to wrap ErrVeilMismatch", err)
+	}
+
+	if err := withExitCode(ErrCrashLoop); errors.As(err, &coder) {
+		t.Errorf("withExitCode(crash loop) has exit code %d, want none", coder.ExitCode())
+	}
+	if err := withExitCode(nil); err != nil {
+		t.Errorf("withExitCode(nil) = %v, want nil", err)
+	}
+}
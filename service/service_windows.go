@@ -4,16 +4,52 @@
 package service
 
 import (
-	"context"
+	"errors"
 	"os"
-	"time"
 
+	"github.com/veil-net/conflux/anchor"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
-	"github.com/veil-net/conflux/anchor"
-	pb "github.com/veil-net/conflux/proto"
 )
 
+// exitVeilMismatch is the exit status on a veil mismatch, reported to the SCM as the service's exit code. Install sets
+// no recovery actions, so the SCM does not start the service again on its own.
+const exitVeilMismatch = 78
+
+// serviceName returns the SCM service name of the selected instance: VeilNet Conflux, or VeilNet Conflux <instance>.
+func serviceName() string {
+	if instance := anchor.Instance(); instance != "" {
+		return "VeilNet Conflux " + instance
+	}
+	return "VeilNet Conflux"
+}
+
+// installedInstances returns the instances whose service is registered in the SCM.
+//
+// Inputs: none.
+//
+// Outputs:
+//   - instances: []string. The instance names, "" for the default instance.
+//   - err: error. Non-nil if the SCM cannot be queried.
+func installedInstances() ([]string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+	names, err := m.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	var instances []string
+	for _, name := range names {
+		if instance, ok := instanceOf(name, "VeilNet Conflux", " "); ok {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
+
 // service is the Windows implementation holding the ServiceImpl; it implements svc.Handler via Execute.
 type service struct {
 	serviceImpl *ServiceImpl
@@ -44,14 +80,12 @@ func (s *service) Run() error {
 
 	// If the conflux is running as a Windows service, run as a Windows service
 	if isWindowsService {
-		svc.Run("VeilNet Conflux", s)
+		svc.Run(serviceName(), s)
 		return nil
 	}
 
 	// Run the API
-	s.serviceImpl.Run()
-
-	return nil
+	return s.serviceImpl.Run()
 }
 
 // Install creates and starts the conflux service in the Windows SCM.
@@ -60,8 +94,12 @@ func (s *service) Run() error {
 //   - s: *service. The Windows service.
 //
 // Outputs:
-//   - err: error. Non-nil if the SCM call fails.
+//   - err: error. ErrOtherInstanceInstalled if another instance's service is registered; non-nil if the SCM call fails.
 func (s *service) Install() error {
+	// Only one instance can hold the anchor port
+	if err := checkOtherInstances(); err != nil {
+		return err
+	}
 
 	// Get the executable path
 	exe, err := os.Executable()
@@ -80,14 +118,23 @@ func (s *service) Install() error {
 
 	// Create the service configuration
 	cfg := mgr.Config{
-		DisplayName:      "VeilNet Conflux",
+		DisplayName:      serviceName(),
 		StartType:        mgr.StartAutomatic,
 		Description:      "VeilNet Conflux service",
 		ServiceStartName: "LocalSystem",
 	}
 
+	// Point the service at the same config directory and instance
+	var args []string
+	if configDir := anchor.ConfigDirOverride(); configDir != "" {
+		args = append(args, "--config-dir", configDir)
+	}
+	if instance := anchor.Instance(); instance != "" {
+		args = append(args, "--instance", instance)
+	}
+
 	// Create the service
-	service, err := m.CreateService("VeilNet Conflux", exe, cfg)
+	service, err := m.CreateService(serviceName(), exe, cfg, args...)
 	if err != nil {
 		Logger.Sugar().Errorf("failed to create service: %v", err)
 		return err
@@ -120,7 +167,7 @@ func (s *service) Start() error {
 	defer m.Disconnect()
 
 	// Open the service
-	service, err := m.OpenService("VeilNet Conflux")
+	service, err := m.OpenService(serviceName())
 	if err != nil {
 		Logger.Sugar().Errorf("failed to open service: %v", err)
 		return err
@@ -155,7 +202,7 @@ func (s *service) Stop() error {
 	defer m.Disconnect()
 
 	// Open the service
-	service, err := m.OpenService("VeilNet Conflux")
+	service, err := m.OpenService(serviceName())
 	if err != nil {
 		Logger.Sugar().Errorf("failed to open service: %v", err)
 		return err
@@ -190,7 +237,7 @@ func (s *service) Remove() error {
 	defer m.Disconnect()
 
 	// Open the service
-	service, err := m.OpenService("VeilNet Conflux")
+	service, err := m.OpenService(serviceName())
 	if err != nil {
 		Logger.Sugar().Errorf("failed to open service: %v", err)
 		return err
@@ -233,7 +280,7 @@ func (s *service) Status() error {
 	defer m.Disconnect()
 
 	// Open the service
-	service, err := m.OpenService("VeilNet Conflux")
+	service, err := m.OpenService(serviceName())
 	if err != nil {
 		Logger.Sugar().Errorf("failed to open service: %v", err)
 		return err
@@ -250,7 +297,7 @@ func (s *service) Status() error {
 	return nil
 }
 
-// Execute implements the Windows service handler: StartPending, start anchor, Running, then handle Stop, Shutdown, and Interrogate.
+// Execute implements the Windows service handler: StartPending, start anchor and config watcher, Running, then handle Stop, Shutdown, and Interrogate.
 //
 // Inputs:
 //   - s: *service. The Windows service.
@@ -260,60 +307,58 @@ func (s *service) Status() error {
 //
 // Outputs:
 //   - ssec: bool. As required by the svc package.
-//   - errno: uint32. As required by the svc package; 0 when the service stops.
+//   - errno: uint32. As required by the svc package; 0 when the service stops, 1 when the anchor crash loops, and
+//     exitVeilMismatch when it connects to a veil the conflux is not pinned to.
 func (s *service) Execute(args []string, changeRequests <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
 
 	// Signal the service is starting
 	changes <- svc.Status{State: svc.StartPending}
 
-	// Load the configuration
-	config, err := anchor.LoadConfig()
-	if err != nil {
-		Logger.Sugar().Fatalf("failed to load configuration: %v", err)
-		return
+	// Start the conflux (anchor subprocess, anchor)
+	err := s.serviceImpl.Start()
+	if errors.Is(err, anchor.ErrVeilMismatch) {
+		Logger.Sugar().Errorf("failed to start conflux: %v", err)
+		return false, exitVeilMismatch
 	}
-
-	// Initialize the anchor plugin
-	subprocess, err := anchor.NewAnchor()
 	if err != nil {
-		Logger.Sugar().Fatalf("failed to initialize anchor plugin: %v", err)
+		Logger.Sugar().Fatalf("failed to start conflux: %v", err)
 		return
 	}
-	defer subprocess.Process.Kill()
+	defer s.serviceImpl.Stop()
 
-	// Wait for the subprocess to start
-	time.Sleep(1 * time.Second)
-
-	// Create a gRPC client connection
-	anchor, err := anchor.NewAnchorClient()
-	if err != nil {
-		Logger.Sugar().Fatalf("failed to create anchor gRPC client: %v", err)
-		return
-	}
-
-	// Start the anchor
-	_, err = anchor.StartAnchor(context.Background(), &pb.StartAnchorRequest{
-		GuardianUrl:  config.Guardian,
-		AnchorToken:   config.Token,
-		Ip:            config.IP,
-		Portal:        !config.Rift,
-	})
-	if err != nil {
-		Logger.Sugar().Fatalf("failed to start anchor: %v", err)
-		return
-	}
+	// Apply edits to the config files without a restart where possible
+	stopWatcher := s.serviceImpl.startConfigWatcher()
+	defer stopWatcher()
 
 	// Set the status to running
 	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
 
-	// Monitor for service control requests and anchor context
-	for changeRequest := range changeRequests {
+	// Monitor for service control requests and the anchor supervisor
+	for {
+		var changeRequest svc.ChangeRequest
+		var ok bool
+		select {
+		case changeRequest, ok = <-changeRequests:
+			if !ok {
+				return false, 0
+			}
+		case err := <-s.serviceImpl.Failed():
+			// Stop with an error so the SCM recovery actions can restart the service
+			changes <- svc.Status{State: svc.StopPending}
+			stopWatcher()
+			s.serviceImpl.Stop()
+			if errors.Is(err, anchor.ErrVeilMismatch) {
+				return false, exitVeilMismatch
+			}
+			return false, 1
+		}
 		switch changeRequest.Cmd {
 		case svc.Interrogate:
 			changes <- changeRequest.CurrentStatus
 		case svc.Stop, svc.Shutdown:
-			// changes <- svc.Status{State: svc.StopPending}
-			// anchor.StopAnchor(context.Background(), &emptypb.Empty{})
+			changes <- svc.Status{State: svc.StopPending}
+			stopWatcher()
+			s.serviceImpl.Stop()
 			changes <- svc.Status{State: svc.Stopped}
 			return false, 0
 		default:
@@ -321,5 +366,4 @@ func (s *service) Execute(args []string, changeRequests <-chan svc.ChangeRequest
 			changes <- changeRequest.CurrentStatus
 		}
 	}
-	return false, 0
 }
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+)
+
+const (
+	// restartBackoffBase is the delay before the first restart after the anchor exits; it doubles with each restart.
+	restartBackoffBase = 1 * time.Second
+	// restartBackoffMax caps the delay between restarts.
+	restartBackoffMax = 1 * time.Minute
+	// anchorStableAfter is how long an anchor must run before its exit restarts the backoff from the beginning.
+	anchorStableAfter = 1 * time.Minute
+	// crashLoopWindow and crashLoopLimit define a crash loop: that many exits or failed restarts within the window.
+	crashLoopWindow = 10 * time.Minute
+	crashLoopLimit  = 5
+)
+
+// clock is the time source of the supervisor.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ErrCrashLoop is returned by Run when the anchor subprocess keeps exiting, so the service manager can take over.
+var ErrCrashLoop = errors.New("anchor subprocess is crash looping")
+
+// supervise restarts the anchor subprocess when it exits unexpectedly, with exponential backoff, and re-issues
+// StartAnchor and the taints on the new one. It gives up and fails the service on a crash loop, and at once when the
+// new anchor connects to a veil the conflux is not pinned to, since Guardian assigns the same veil again. It returns when ctx
+// is done, which stop does before it kills the subprocess on purpose.
+//
+// Inputs:
+//   - ctx: context.Context. Cancelled when the service stops.
+//   - subprocess: *exec.Cmd. The running anchor subprocess.
+//
+// Outputs: none. Exits, restarts, and failures are logged and recorded in the anchor status.
+func (s *ServiceImpl) supervise(ctx context.Context, subprocess *exec.Cmd) {
+	var exits []time.Time
+	startedAt := s.clock.Now()
+	backoff := restartBackoffBase
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.exited(subprocess):
+		}
+
+		s.mu.Lock()
+		if ctx.Err() != nil || s.subprocess != subprocess {
+			s.mu.Unlock()
+			return
+		}
+		s.subprocess = nil
+		s.client = nil
+		s.status.LastExit = subprocess.ProcessState.String()
+		s.status.LastExitAt = s.clock.Now()
+		s.saveStatus()
+		s.mu.Unlock()
+		Logger.Sugar().Errorf("anchor subprocess exited unexpectedly: %s", subprocess.ProcessState)
+
+		if s.clock.Now().Sub(startedAt) >= anchorStableAfter {
+			backoff = restartBackoffBase
+		}
+		for {
+			now := s.clock.Now()
+			exits = append(slices.DeleteFunc(exits, func(exit time.Time) bool { return now.Sub(exit) > crashLoopWindow }), now)
+			if len(exits) >= crashLoopLimit {
+				s.giveUp(fmt.Errorf("%w: it exited or failed to restart %d times within %s", ErrCrashLoop, len(exits), crashLoopWindow))
+				return
+			}
+
+			Logger.Sugar().Infof("restarting anchor subprocess in %s", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clock.After(backoff):
+			}
+			backoff = min(backoff*2, restartBackoffMax)
+
+			next, err := s.restartAnchor(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				subprocess = next
+				startedAt = s.clock.Now()
+				break
+			}
+			if errors.Is(err, anchor.ErrVeilMismatch) {
+				s.giveUp(err)
+				return
+			}
+			Logger.Sugar().Errorf("failed to restart anchor subprocess: %v", err)
+		}
+	}
+}
+
+// restartAnchor starts a new anchor subprocess and re-issues StartAnchor with the conflux token, the region/veil pin,
+// and the taints.
+//
+// Inputs:
+//   - ctx: context.Context. Cancelled when the service stops.
+//
+// Outputs:
+//   - *exec.Cmd. The new subprocess.
+//   - err: error. Non-nil if the subprocess does not become ready or the anchor cannot be started; it is killed then.
+func (s *ServiceImpl) restartAnchor(ctx context.Context) (*exec.Cmd, error) {
+	// Wait for the new subprocess without s.mu, so a stop is not held up by it
+	subprocess, client, err := s.launch(ctx, anchor.DefaultReadyOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		subprocess.Process.Kill()
+		return nil, ctx.Err()
+	}
+	s.subprocess = subprocess
+	s.client = client
+	if err := s.startAnchor(ctx); err != nil {
+		subprocess.Process.Kill()
+		s.subprocess = nil
+		s.client = nil
+		return nil, err
+	}
+
+	s.status.Restarts++
+	s.status.PID = subprocess.Process.Pid
+	s.status.StartedAt = s.clock.Now()
+	s.saveStatus()
+	Logger.Sugar().Infof("anchor subprocess restarted (%d restarts so far)", s.status.Restarts)
+	return subprocess, nil
+}
+
+// giveUp records whether err is a crash loop and fails the service with it.
+func (s *ServiceImpl) giveUp(err error) {
+	s.mu.Lock()
+	s.status.CrashLoop = errors.Is(err, ErrCrashLoop)
+	s.saveStatus()
+	s.mu.Unlock()
+	Logger.Sugar().Errorf("giving up on the anchor: %v", err)
+	select {
+	case s.failed <- err:
+	default:
+	}
+}
+
+// saveStatus writes the anchor status for `conflux info anchor`; the caller holds s.mu.
+func (s *ServiceImpl) saveStatus() {
+	if err := anchor.SaveAnchorStatus(&s.status); err != nil {
+		Logger.Sugar().Warnf("failed to record anchor status: %v", err)
+	}
+}
+
+// Restarts returns how often the supervisor has restarted the anchor subprocess since the service started.
+//
+// Inputs:
+//   - s: *ServiceImpl. The implementation.
+//
+// Outputs:
+//   - int. The restart count.
+func (s *ServiceImpl) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status.Restarts
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/veil-net/conflux/anchor"
+	pb "github.com/veil-net/conflux/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeAnchorEnv makes the test binary act as an anchor subprocess that runs until it is killed.
+const fakeAnchorEnv = "CONFLUX_TEST_FAKE_ANCHOR"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeAnchorEnv) != "" {
+		time.Sleep(time.Hour)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeClock is a clock whose backoff waits the test observes and lets pass.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits chan fakeWait
+}
+
+// fakeWait is one After call of the supervisor.
+type fakeWait struct {
+	d    time.Duration
+	fire chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), waits: make(chan fakeWait, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	wait := fakeWait{d: d, fire: make(chan time.Time, 1)}
+	c.waits <- wait
+	return wait.fire
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// next returns the next wait of the supervisor.
+func (c *fakeClock) next(t *testing.T) fakeWait {
+	t.Helper()
+	select {
+	case wait := <-c.waits:
+		return wait
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor did not wait to restart the anchor")
+		return fakeWait{}
+	}
+}
+
+// pass advances the clock by elapsed and ends the wait.
+func (c *fakeClock) pass(wait fakeWait, elapsed time.Duration) {
+	c.advance(elapsed)
+	wait.fire <- c.Now()
+}
+
+// fakeAnchors launches the test binary as anchor subprocesses, with a client that accepts every call.
+type fakeAnchors struct {
+	mu        sync.Mutex
+	exits     map[*exec.Cmd]chan struct{}
+	launched  []*exec.Cmd
+	launchErr error
+	veil      string
+}
+
+func (f *fakeAnchors) launch(ctx context.Context, options anchor.ReadyOptions) (*exec.Cmd, pb.AnchorClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.launchErr != nil {
+		return nil, nil, f.launchErr
+	}
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), fakeAnchorEnv+"=1")
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	if f.exits == nil {
+		f.exits = map[*exec.Cmd]chan struct{}{}
+	}
+	f.exits[cmd] = done
+	f.launched = append(f.launched, cmd)
+	return cmd, &fakeClient{veil: f.veil}, nil
+}
+
+func (f *fakeAnchors) exited(subprocess *exec.Cmd) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.exits[subprocess]
+}
+
+func (f *fakeAnchors) set(launchErr error, veil string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.launchErr = launchErr
+	f.veil = veil
+}
+
+// crash kills the latest subprocess and waits until it has exited.
+func (f *fakeAnchors) crash(t *testing.T) *exec.Cmd {
+	t.Helper()
+	cmd := f.latest()
+	done := f.exited(cmd)
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	return cmd
+}
+
+// latest returns the latest subprocess.
+func (f *fakeAnchors) latest() *exec.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.launched[len(f.launched)-1]
+}
+
+// fakeClient answers the anchor calls the service makes; the veil it reports is fixed at launch.
+type fakeClient struct {
+	pb.AnchorClient
+	veil string
+}
+
+func (c *fakeClient) StartAnchor(ctx context.Context, in *pb.StartAnchorRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeClient) StopAnchor(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeClient) AddTaint(ctx context.Context, in *pb.AddTaintRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeClient) GetVeilInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*pb.GetVeilInfoResponse, error) {
+	return &pb.GetVeilInfoResponse{VeilHost: c.veil}, nil
+}
+
+// startSupervised starts a service on fake anchors and a fake clock; it is stopped when the test ends.
+func startSupervised(t *testing.T, anchors *fakeAnchors, config *anchor.ConfluxConfig) (*ServiceImpl, *fakeClock) {
+	t.Helper()
+	anchor.SetConfigDir(t.TempDir())
+	t.Cleanup(func() { anchor.SetConfigDir("") })
+
+	clock := newFakeClock()
+	s := NewServiceImpl()
+	s.clock = clock
+	s.launch = anchors.launch
+	s.exited = anchors.exited
+	s.mu.Lock()
+	err := s.startWith(config)
+	s.mu.Unlock()
+	if err != nil {
+		t.Fatalf("startWith: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s, clock
+}
+
+// eventually fails the test unless cond holds within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func testSupervisedConfig() *anchor.ConfluxConfig {
+	return &anchor.ConfluxConfig{ConfluxID: "conflux-1", Token: "token", Guardian: "https://guardian.test", Taints: []string{"dev"}}
+}
+
+func TestSuperviseBackoff(t *testing.T) {
+	anchors := &fakeAnchors{}
+	s, clock := startSupervised(t, anchors, testSupervisedConfig())
+	anchors.set(errors.New("anchor did not become ready"), "")
+	anchors.crash(t)
+
+	// Three minutes between failed restarts keep fewer than crashLoopLimit of them within crashLoopWindow
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		16 * time.Second, 32 * time.Second, time.Minute, time.Minute} {
+		wait := clock.next(t)
+		if wait.d != want {
+			t.Fatalf("backoff = %s, want %s", wait.d, want)
+		}
+		clock.pass(wait, wait.d+3*time.Minute)
+	}
+	clock.next(t)
+	select {
+	case err := <-s.Failed():
+		t.Errorf("supervisor gave up: %v", err)
+	default:
+	}
+}
+
+func TestSuperviseCrashLoop(t *testing.T) {
+	anchors := &fakeAnchors{}
+	s, clock := startSupervised(t, anchors, testSupervisedConfig())
+	anchors.set(errors.New("anchor did not become ready"), "")
+	anchors.crash(t)
+
+	// The exit and four failed restarts within crashLoopWindow
+	for range crashLoopLimit - 1 {
+		wait := clock.next(t)
+		clock.pass(wait, wait.d)
+	}
+	select {
+	case err := <-s.Failed():
+		if !errors.Is(err, ErrCrashLoop) {
+			t.Errorf("Failed = %v, want ErrCrashLoop", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor did not give up on the crash loop")
+	}
+	s.mu.Lock()
+	crashLoop := s.status.CrashLoop
+	s.mu.Unlock()
+	if !crashLoop {
+		t.Error("status does not record the crash loop")
+	}
+	select {
+	case wait := <-clock.waits:
+		t.Errorf("supervisor waited %s to restart after giving up", wait.d)
+	default:
+	}
+}
+
+func TestSuperviseRecordsRestarts(t *testing.T) {
+	anchors := &fakeAnchors{}
+	s, clock := startSupervised(t, anchors, testSupervisedConfig())
+
+	status := func() anchor.AnchorStatus {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.status
+	}
+	steps := []struct {
+		// ran is how long the subprocess runs before it crashes
+		ran     time.Duration
+		backoff time.Duration
+	}{
+		{0, time.Second},
+		// An anchor that ran for anchorStableAfter restarts the backoff from the beginning
+		{anchorStableAfter, time.Second},
+		{0, 2 * time.Second},
+	}
+	for i, step := range steps {
+		clock.advance(step.ran)
+		crashed := anchors.crash(t)
+		wait := clock.next(t)
+		if wait.d != step.backoff {
+			t.Fatalf("restart %d: backoff = %s, want %s", i+1, wait.d, step.backoff)
+		}
+		if got := status(); got.LastExit != crashed.ProcessState.String() || !got.LastExitAt.Equal(clock.Now()) {
+			t.Errorf("restart %d: last exit = %q at %s, want %q at %s", i+1, got.LastExit, got.LastExitAt, crashed.ProcessState, clock.Now())
+		}
+		clock.pass(wait, wait.d)
+
+		eventually(t, "the restart", func() bool { return s.Restarts() == i+1 })
+		got := status()
+		if latest := anchors.latest(); got.PID != latest.Process.Pid {
+			t.Errorf("restart %d: status PID = %d, want %d", i+1, got.PID, latest.Process.Pid)
+		}
+		if !got.StartedAt.Equal(clock.Now()) || got.CrashLoop {
+			t.Errorf("restart %d: status = %+v, want started now without a crash loop", i+1, got)
+		}
+	}
+	saved, err := anchor.LoadAnchorStatus()
+	if err != nil || saved.Restarts != len(steps) {
+		t.Errorf("saved status = %+v, %v, want %d restarts", saved, err, len(steps))
+	}
+}
+
+func TestSuperviseGivesUpOnVeilMismatch(t *testing.T) {
+	anchors := &fakeAnchors{veil: "veil-a"}
+	config := testSupervisedConfig()
+	config.Veil = "veil-a"
+	s, clock := startSupervised(t, anchors, config)
+
+	// Guardian assigns the conflux another veil after the restart
+	anchors.set(nil, "veil-b")
+	anchors.crash(t)
+	wait := clock.next(t)
+	clock.pass(wait, wait.d)
+
+	select {
+	case err := <-s.Failed():
+		if !errors.Is(err, anchor.ErrVeilMismatch) {
+			t.Errorf("Failed = %v, want ErrVeilMismatch", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor did not give up on the veil mismatch")
+	}
+	select {
+	case wait := <-clock.waits:
+		t.Errorf("supervisor waited %s to restart into the wrong veil", wait.d)
+	default:
+	}
+	if s.Restarts() != 0 {
+		t.Errorf("restarts = %d, want 0", s.Restarts())
+	}
+}